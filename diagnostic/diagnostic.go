@@ -0,0 +1,229 @@
+package diagnostic
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/5anthosh/chili/parser/token"
+)
+
+//Error codes
+const (
+	//CodeSyntax is code of SyntaxError
+	CodeSyntax = "E100"
+	//CodeType is code of TypeError
+	CodeType = "E200"
+	//CodeUndefinedSymbol is code of UndefinedSymbolError
+	CodeUndefinedSymbol = "E300"
+	//CodeArity is code of ArityError
+	CodeArity = "E400"
+	//CodeDivisionByZero is code of DivisionByZeroError
+	CodeDivisionByZero = "E500"
+	//CodeValue is code of ValueError
+	CodeValue = "E600"
+	//CodeRecursion is code of RecursionError
+	CodeRecursion = "E700"
+)
+
+//Diagnostic is error with position in the source, it is embedded in all the typed errors
+type Diagnostic struct {
+	//Span of the source which caused the error, zero when position is not known yet
+	Span token.Span
+	//Code of the error
+	Code string
+	//Message of the error, message of Err is used when empty
+	Message string
+	//Source of the expression, used by Pretty
+	Source string
+	//Err is the cause of the error
+	Err error
+}
+
+//Positioned is implemented by all the typed errors
+type Positioned interface {
+	error
+	Base() *Diagnostic
+}
+
+func (d *Diagnostic) Error() string {
+	if d.Message == "" && d.Err != nil {
+		return d.Err.Error()
+	}
+	return d.Message
+}
+
+//Unwrap returns the cause of the error
+func (d *Diagnostic) Unwrap() error {
+	return d.Err
+}
+
+//Base returns the diagnostic of the error
+func (d *Diagnostic) Base() *Diagnostic {
+	return d
+}
+
+//Located tells whether span of the error is known
+func (d *Diagnostic) Located() bool {
+	return d.Span != token.Span{}
+}
+
+//Pretty renders the error with the source line and caret underline
+//
+//	1:5: Unknown variable x
+//	  1 | 1 + x
+//	    |     ^
+func (d *Diagnostic) Pretty() string {
+	return d.pretty(d.Error())
+}
+
+func (d *Diagnostic) pretty(message string) string {
+	if !d.Located() {
+		return message
+	}
+	header := fmt.Sprintf("%s: %s", d.Span.Start.String(), message)
+	lines := strings.Split(d.Source, "\n")
+	if d.Source == "" || int(d.Span.Start.Line) > len(lines) {
+		return header
+	}
+	line := strings.TrimRight(lines[d.Span.Start.Line-1], "\r")
+	column := int(d.Span.Start.Column) - 1
+	if column > len(line) {
+		column = len(line)
+	}
+	end := len(line)
+	if d.Span.End.Line == d.Span.Start.Line && int(d.Span.End.Column)-1 < end {
+		end = int(d.Span.End.Column) - 1
+	}
+	width := utf8.RuneCountInString(line[column:maxInt(column, end)])
+	if width == 0 {
+		width = 1
+	}
+	number := fmt.Sprintf("%d", d.Span.Start.Line)
+	gutter := strings.Repeat(" ", len(number))
+	var builder strings.Builder
+	builder.WriteString(header)
+	builder.WriteString("\n " + number + " | " + line)
+	builder.WriteString("\n " + gutter + " | ")
+	builder.WriteString(strings.Repeat(" ", utf8.RuneCountInString(line[:column])))
+	builder.WriteString(strings.Repeat("^", width))
+	return builder.String()
+}
+
+//SyntaxError is error in lexing or parsing the source
+type SyntaxError struct {
+	Diagnostic
+}
+
+//NewSyntaxError #
+func NewSyntaxError(span token.Span, format string, a ...interface{}) *SyntaxError {
+	return &SyntaxError{Diagnostic{Span: span, Code: CodeSyntax, Message: fmt.Sprintf(format, a...)}}
+}
+
+//TypeError is operation on value of unsupported datatype
+type TypeError struct {
+	Diagnostic
+}
+
+//NewTypeError #
+func NewTypeError(span token.Span, format string, a ...interface{}) *TypeError {
+	return &TypeError{Diagnostic{Span: span, Code: CodeType, Message: fmt.Sprintf(format, a...)}}
+}
+
+//UndefinedSymbolError is use of variable or function which is not declared
+type UndefinedSymbolError struct {
+	Diagnostic
+	Name string
+}
+
+//NewUndefinedSymbolError #
+func NewUndefinedSymbolError(span token.Span, name string, format string, a ...interface{}) *UndefinedSymbolError {
+	return &UndefinedSymbolError{
+		Diagnostic: Diagnostic{Span: span, Code: CodeUndefinedSymbol, Message: fmt.Sprintf(format, a...)},
+		Name:       name,
+	}
+}
+
+//ArityError is call with wrong number of arguments
+type ArityError struct {
+	Diagnostic
+	Name string
+}
+
+//NewArityError #
+func NewArityError(span token.Span, name string, format string, a ...interface{}) *ArityError {
+	return &ArityError{
+		Diagnostic: Diagnostic{Span: span, Code: CodeArity, Message: fmt.Sprintf(format, a...)},
+		Name:       name,
+	}
+}
+
+//DivisionByZeroError is division or modulo by zero, err is the sentinel error it wraps
+type DivisionByZeroError struct {
+	Diagnostic
+}
+
+//NewDivisionByZeroError #
+func NewDivisionByZeroError(span token.Span, err error) *DivisionByZeroError {
+	return &DivisionByZeroError{Diagnostic{Span: span, Code: CodeDivisionByZero, Err: err}}
+}
+
+//ValueError is value of supported datatype which cannot be used, like list index
+//out of range, invalid regular expression or range too large to iterate
+type ValueError struct {
+	Diagnostic
+}
+
+//NewValueError #
+func NewValueError(span token.Span, format string, a ...interface{}) *ValueError {
+	return &ValueError{Diagnostic{Span: span, Code: CodeValue, Message: fmt.Sprintf(format, a...)}}
+}
+
+//RecursionError is recursive call of user defined function or calls nested deeper than allowed
+type RecursionError struct {
+	Diagnostic
+	Name string
+}
+
+//NewRecursionError #
+func NewRecursionError(span token.Span, name string, format string, a ...interface{}) *RecursionError {
+	return &RecursionError{
+		Diagnostic: Diagnostic{Span: span, Code: CodeRecursion, Message: fmt.Sprintf(format, a...)},
+		Name:       name,
+	}
+}
+
+//Locate sets span of the error when it is not known yet
+func Locate(err error, span token.Span) error {
+	var positioned Positioned
+	if errors.As(err, &positioned) && !positioned.Base().Located() {
+		positioned.Base().Span = span
+	}
+	return err
+}
+
+//WithSource attaches source to the error so that Pretty can print the source line
+func WithSource(err error, source string) error {
+	var positioned Positioned
+	if errors.As(err, &positioned) && positioned.Base().Source == "" {
+		positioned.Base().Source = source
+	}
+	return err
+}
+
+//Pretty renders err with source line of the positioned error it wraps
+func Pretty(err error) string {
+	var positioned Positioned
+	if errors.As(err, &positioned) {
+		return positioned.Base().pretty(err.Error())
+	}
+	return err.Error()
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -1,9 +1,14 @@
 package environment
 
 import (
+	"errors"
 	"fmt"
 
+	"github.com/5anthosh/chili/diagnostic"
 	"github.com/5anthosh/chili/function"
+	"github.com/5anthosh/chili/operator"
+	"github.com/5anthosh/chili/parser"
+	"github.com/5anthosh/chili/parser/token"
 	"github.com/shopspring/decimal"
 )
 
@@ -13,11 +18,16 @@ const (
 	variableType
 )
 
+//ErrReservedWord is wrapped by errors for names which are keywords of the language
+var ErrReservedWord = errors.New("reserved word")
+
 //Environment of evaluator
 type Environment struct {
 	symbolTable map[string]uint
 	variables   map[string]interface{}
 	functions   map[string]function.Function
+	operators   *operator.Table
+	parent      *Environment
 }
 
 //New Environment
@@ -26,11 +36,29 @@ func New() *Environment {
 		symbolTable: make(map[string]uint),
 		variables:   make(map[string]interface{}),
 		functions:   make(map[string]function.Function),
+		operators:   operator.NewTable(),
 	}
 }
 
+//NewChild creates scope enclosed by the environment,
+//names declared in child scope shadow the names in enclosing scopes
+func (e *Environment) NewChild() *Environment {
+	child := New()
+	child.parent = e
+	child.operators = e.operators
+	return child
+}
+
+//Parent scope of the environment
+func (e *Environment) Parent() *Environment {
+	return e.parent
+}
+
 //SetFunction to Evaluator
 func (e *Environment) SetFunction(function function.Function) error {
+	if token.IsKeyword(function.Name) {
+		return reservedWordErr(function.Name, "function")
+	}
 	if e.CheckSymbolTable(function.Name) {
 		return fmt.Errorf("%s is already declared", function.Name)
 	}
@@ -39,6 +67,32 @@ func (e *Environment) SetFunction(function function.Function) error {
 	return nil
 }
 
+//RegisterOperator adds custom operator, it is shared by all scopes of the environment.
+//Prefix keyword operator cannot take name of declared function or variable since
+//keyword before an operand is scanned as the operator
+func (e *Environment) RegisterOperator(op operator.Operator) error {
+	if op.Kind == operator.Prefix && e.IsDeclared(op.Symbol) {
+		return fmt.Errorf("operator %s is already declared as function or variable", op.Symbol)
+	}
+	return e.operators.Add(op)
+}
+
+//Operators registered in the environment
+func (e *Environment) Operators() *operator.Table {
+	return e.operators
+}
+
+//DefineFunction written in chili, def name(a, b) = body
+func (e *Environment) DefineFunction(source string) error {
+	def, err := parser.NewWithOptions(source, parser.Options{Operators: e.operators}).ParseFunction()
+	if err != nil {
+		return diagnostic.WithSource(err, source)
+	}
+	userFunction := function.UserFunction(def.Name, def.Params, def.Body)
+	userFunction.Source = source
+	return e.SetFunction(userFunction)
+}
+
 //SetDefaultFunctions to environment
 func (e *Environment) SetDefaultFunctions() error {
 	funcs := function.DefaultFunctions
@@ -88,6 +142,20 @@ func (e *Environment) SetInt32Variable(name string, value int32) error {
 
 //DeclareVariable in the environment
 func (e *Environment) DeclareVariable(name string, value interface{}) error {
+	if token.IsKeyword(name) {
+		return reservedWordErr(name, "variable")
+	}
+	if e.CheckSymbolTable(name) {
+		return fmt.Errorf("%s is already declared", name)
+	}
+	e.symbolTableEntry(name, variableType)
+	e.variables[name] = value
+	return nil
+}
+
+//DeclareData declares variable of evaluation data, unlike DeclareVariable it accepts
+//keywords as names since data is referred by quoted identifier @"end" or prop("end")
+func (e *Environment) DeclareData(name string, value interface{}) error {
 	if e.CheckSymbolTable(name) {
 		return fmt.Errorf("%s is already declared", name)
 	}
@@ -96,36 +164,96 @@ func (e *Environment) DeclareVariable(name string, value interface{}) error {
 	return nil
 }
 
+//AssignVariable in this scope, declares it when it is not declared in this scope
+func (e *Environment) AssignVariable(name string, value interface{}) error {
+	if token.IsKeyword(name) {
+		return reservedWordErr(name, "variable")
+	}
+	if e.IsFunction(name) {
+		return fmt.Errorf("%s is function, cannot be assigned", name)
+	}
+	e.symbolTableEntry(name, variableType)
+	e.variables[name] = value
+	return nil
+}
+
+func reservedWordErr(name string, kind string) error {
+	return fmt.Errorf("%w: '%s' is a keyword and cannot be used as %s name, rename it", ErrReservedWord, name, kind)
+}
+
+//Variables declared in this scope
+func (e *Environment) Variables() map[string]interface{} {
+	variables := make(map[string]interface{}, len(e.variables))
+	for name, value := range e.variables {
+		variables[name] = value
+	}
+	return variables
+}
+
 //GetVariable from the environment
 func (e *Environment) GetVariable(name string) (interface{}, bool) {
-	value, ok := e.variables[name]
+	scope := e.lookup(name)
+	if scope == nil {
+		return nil, false
+	}
+	value, ok := scope.variables[name]
 	return value, ok
 }
 
 //GetFunction from the environment
 func (e *Environment) GetFunction(name string) (function.Function, bool) {
-	value, ok := e.functions[name]
+	scope := e.lookup(name)
+	if scope == nil {
+		return function.Function{}, false
+	}
+	value, ok := scope.functions[name]
 	return value, ok
 }
 
+//Scope where name is declared, nil if name is not declared
+func (e *Environment) Scope(name string) *Environment {
+	return e.lookup(name)
+}
+
+//lookup the nearest scope where name is declared
+func (e *Environment) lookup(name string) *Environment {
+	for scope := e; scope != nil; scope = scope.parent {
+		if _, ok := scope.symbolTable[name]; ok {
+			return scope
+		}
+	}
+	return nil
+}
+
 func (e *Environment) symbolTableEntry(name string, varType uint) {
 	e.symbolTable[name] = varType
 }
 
-//CheckSymbolTable if variable is registered in symbol table
+//CheckSymbolTable if name is registered in symbol table of this scope
 func (e *Environment) CheckSymbolTable(name string) bool {
 	_, ok := e.symbolTable[name]
 	return ok
 }
 
+//IsDeclared check if name is declared in the environment or its enclosing scopes
+func (e *Environment) IsDeclared(name string) bool {
+	return e.lookup(name) != nil
+}
+
 //IsVariable check if name is variable
 func (e *Environment) IsVariable(name string) bool {
-	varType, _ := e.symbolTable[name]
-	return varType == variableType
+	scope := e.lookup(name)
+	if scope == nil {
+		return false
+	}
+	return scope.symbolTable[name] == variableType
 }
 
 //IsFunction check if name is variable
 func (e *Environment) IsFunction(name string) bool {
-	varType, _ := e.symbolTable[name]
-	return varType == functionType
+	scope := e.lookup(name)
+	if scope == nil {
+		return false
+	}
+	return scope.symbolTable[name] == functionType
 }
@@ -1,9 +1,13 @@
 package environment
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/5anthosh/chili/diagnostic"
 	"github.com/5anthosh/chili/function"
+	"github.com/5anthosh/chili/operator"
+	"github.com/shopspring/decimal"
 )
 
 type fields struct {
@@ -123,3 +127,97 @@ func TestEnvironment_SetFunction(t *testing.T) {
 		})
 	}
 }
+
+func TestEnvironment_NewChild(t *testing.T) {
+	parent := New()
+	parent.SetIntVariable("x", 1)
+	parent.SetIntVariable("y", 2)
+	child := parent.NewChild()
+	if err := child.SetIntVariable("x", 10); err != nil {
+		t.Errorf("Environment.DeclareVariable() in child scope error = %v", err)
+	}
+	x, _ := child.GetVariable("x")
+	if x.(decimal.Decimal).IntPart() != 10 {
+		t.Errorf("child.GetVariable(x) = %v, want 10", x)
+	}
+	y, ok := child.GetVariable("y")
+	if !ok || y.(decimal.Decimal).IntPart() != 2 {
+		t.Errorf("child.GetVariable(y) = %v, want 2", y)
+	}
+	x, _ = parent.GetVariable("x")
+	if x.(decimal.Decimal).IntPart() != 1 {
+		t.Errorf("parent.GetVariable(x) = %v, want 1", x)
+	}
+	if !child.IsVariable("y") || child.CheckSymbolTable("y") {
+		t.Errorf("y should be resolved through the parent scope only")
+	}
+}
+
+func TestEnvironment_DefineFunction(t *testing.T) {
+	env := New()
+	if err := env.DefineFunction("def discount(p, rate) = p * (1 - rate)"); err != nil {
+		t.Fatalf("Environment.DefineFunction() error = %v", err)
+	}
+	discount, ok := env.GetFunction("discount")
+	if !ok || !discount.IsUserFunction() || discount.Arity != 2 {
+		t.Errorf("Environment.GetFunction() = %v, want user function with 2 params", discount)
+	}
+	if err := env.DefineFunction("def discount(p) = p"); err == nil {
+		t.Errorf("Environment.DefineFunction() expected error for redeclaration")
+	}
+	if err := env.DefineFunction("def broken(p) = p +"); err == nil {
+		t.Errorf("Environment.DefineFunction() expected syntax error")
+	}
+	if err := env.DefineFunction("def extra(p) = p p"); err == nil {
+		t.Errorf("Environment.DefineFunction() expected error for trailing tokens")
+	}
+	err := env.DefineFunction("def twice(a, b, a) = a")
+	var syntaxErr *diagnostic.SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Span.Start.Column != 17 {
+		t.Errorf("Environment.DefineFunction() error = %v, want SyntaxError at duplicate parameter", err)
+	}
+	if env.IsDeclared("twice") {
+		t.Errorf("Environment.DefineFunction() declared function with duplicate parameters")
+	}
+}
+
+func TestEnvironment_ReservedWord(t *testing.T) {
+	env := New()
+	for _, name := range []string{"and", "or", "not", "in", "case"} {
+		if err := env.DeclareVariable(name, 1); !errors.Is(err, ErrReservedWord) {
+			t.Errorf("Environment.DeclareVariable(%q) error = %v, want %v", name, err, ErrReservedWord)
+		}
+		if err := env.AssignVariable(name, 1); !errors.Is(err, ErrReservedWord) {
+			t.Errorf("Environment.AssignVariable(%q) error = %v, want %v", name, err, ErrReservedWord)
+		}
+		if err := env.SetFunction(function.Function{Name: name}); !errors.Is(err, ErrReservedWord) {
+			t.Errorf("Environment.SetFunction(%q) error = %v, want %v", name, err, ErrReservedWord)
+		}
+	}
+	if err := env.DeclareVariable("android", 1); err != nil {
+		t.Errorf("Environment.DeclareVariable() error = %v", err)
+	}
+	if err := env.DeclareData("end", 1); err != nil {
+		t.Errorf("Environment.DeclareData() error = %v", err)
+	}
+	if err := env.DefineFunction("def f(x, end) = x"); err == nil {
+		t.Errorf("Environment.DefineFunction() expected reserved word error")
+	}
+}
+
+func TestEnvironment_RegisterOperator(t *testing.T) {
+	env := New()
+	env.SetDefaultFunctions()
+	impl := func(operands []interface{}) (interface{}, error) {
+		return operands[0], nil
+	}
+	if err := env.RegisterOperator(operator.Operator{Symbol: "contains", Precedence: operator.PrecedenceComparison, Impl: impl}); err != nil {
+		t.Errorf("Environment.RegisterOperator() infix error = %v", err)
+	}
+	if err := env.RegisterOperator(operator.Operator{Symbol: "abs", Kind: operator.Prefix, Precedence: operator.PrecedencePrefix, Impl: impl}); err == nil {
+		t.Errorf("Environment.RegisterOperator() expected error for prefix operator named as function")
+	}
+	if err := env.RegisterOperator(operator.Operator{Symbol: "neg", Kind: operator.Prefix, Precedence: operator.PrecedencePrefix, Impl: impl}); err != nil {
+		t.Errorf("Environment.RegisterOperator() prefix error = %v", err)
+	}
+}
@@ -1,43 +1,107 @@
 package chili
 
 import (
+	"math/big"
+	"reflect"
+
+	"github.com/5anthosh/chili/diagnostic"
 	"github.com/5anthosh/chili/environment"
 	"github.com/5anthosh/chili/evaluator"
 	"github.com/5anthosh/chili/evaluator/datatype"
 	"github.com/5anthosh/chili/parser"
+	"github.com/shopspring/decimal"
 )
 
-//Eval the expression
+//Eval the expression, data is declared in scope enclosed by the builtins so that
+//keys like map, filter or E shadow builtin functions and constants of same name
+//and keys which are keywords like end are referred as @"end"
 func Eval(expression string, data map[string]interface{}) (interface{}, error) {
-	env := environment.New()
-	env.SetDefaultFunctions()
-	env.SetDefaultVariables()
-	putDataToEnv(env, data)
-	_parser := parser.New(expression)
-	ast, err := _parser.Parse()
+	builtins := environment.New()
+	builtins.SetDefaultFunctions()
+	builtins.SetDefaultVariables()
+	env := builtins.NewChild()
+	err := putDataToEnv(env, data)
 	if err != nil {
 		return nil, err
 	}
+	_parser := parser.NewWithOptions(expression, parser.Options{Operators: env.Operators()})
+	ast, err := _parser.Parse()
+	if err != nil {
+		return nil, diagnostic.WithSource(err, expression)
+	}
 	_evaluator := evaluator.New(env)
-	return _evaluator.Run(ast)
+	value, err := _evaluator.Run(ast)
+	if err != nil {
+		return nil, diagnostic.WithSource(err, expression)
+	}
+	return value, nil
 }
 
 func putDataToEnv(env *environment.Environment, data map[string]interface{}) error {
 	for k, v := range data {
-		switch v.(type) {
-		case int64:
-			env.SetIntVariable(k, v.(int64))
-		case int32:
-			env.SetInt32Variable(k, v.(int32))
-		case float64:
-			env.SetFloatVariable(k, v.(float64))
-		case float32:
-			env.SetFloat32Variable(k, v.(float32))
-		case string, bool:
-			env.DeclareVariable(k, v)
-		default:
-			return datatype.ErrUnknownDataype
+		value, err := toValue(v)
+		if err != nil {
+			return err
+		}
+		err = env.DeclareData(k, value)
+		if err != nil {
+			return err
 		}
 	}
 	return nil
 }
+
+//toValue converts go value into chili datatype
+func toValue(v interface{}) (interface{}, error) {
+	switch v.(type) {
+	case nil, decimal.Decimal, string, bool:
+		return v, nil
+	}
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return decimal.NewFromInt(value.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return decimal.NewFromBigInt(new(big.Int).SetUint64(value.Uint()), 0), nil
+	case reflect.Float32:
+		return decimal.NewFromFloat32(float32(value.Float())), nil
+	case reflect.Float64:
+		return decimal.NewFromFloat(value.Float()), nil
+	case reflect.String:
+		return value.String(), nil
+	case reflect.Bool:
+		return value.Bool(), nil
+	case reflect.Slice, reflect.Array:
+		return toList(value)
+	case reflect.Map:
+		if value.Type().Key().Kind() == reflect.String {
+			return toMap(value)
+		}
+	}
+	return nil, datatype.ErrUnknownDataype
+}
+
+func toList(value reflect.Value) (interface{}, error) {
+	list := make([]interface{}, 0, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		element, err := toValue(value.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, element)
+	}
+	return list, nil
+}
+
+func toMap(value reflect.Value) (interface{}, error) {
+	object := make(map[string]interface{}, value.Len())
+	iter := value.MapRange()
+	for iter.Next() {
+		element, err := toValue(iter.Value().Interface())
+		if err != nil {
+			return nil, err
+		}
+		object[iter.Key().String()] = element
+	}
+	return object, nil
+}
@@ -1,9 +1,11 @@
 package chili
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
+	"github.com/5anthosh/chili/diagnostic"
 	"github.com/5anthosh/chili/environment"
 	"github.com/5anthosh/chili/evaluator"
 	"github.com/5anthosh/chili/parser"
@@ -29,3 +31,531 @@ func TestEvalNumberFunction(t *testing.T) {
 		t.Errorf("Evaluation = %s but want %s", fmt.Sprintf("%v", value), "57.905")
 	}
 }
+
+type evalTest struct {
+	name       string
+	expression string
+	data       map[string]interface{}
+	want       string
+	wantErr    bool
+}
+
+func runEvalTests(t *testing.T, tests []evalTest) {
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Eval(tt.expression, tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Eval(%q) error = %v, wantErr %v", tt.expression, err, tt.wantErr)
+				return
+			}
+			if err == nil && fmt.Sprintf("%v", got) != tt.want {
+				t.Errorf("Eval(%q) = %v, want %v", tt.expression, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalList(t *testing.T) {
+	data := map[string]interface{}{
+		"tags":       []string{"new", "sale"},
+		"thresholds": []int{10, 20, 30},
+		"empty":      []interface{}{},
+	}
+	runEvalTests(t, []evalTest{
+		{name: "literal", expression: "[1, 'a', true]", want: "[1 a true]"},
+		{name: "empty literal", expression: "[]", want: "[]"},
+		{name: "index", expression: "thresholds[1]", data: data, want: "20"},
+		{name: "negative index", expression: "tags[-1]", data: data, want: "sale"},
+		{name: "nested index", expression: "[[1, 2], [3, 4]][1][0]", want: "3"},
+		{name: "index out of range", expression: "thresholds[3]", data: data, wantErr: true},
+		{name: "fractional index", expression: "thresholds[0.5]", data: data, wantErr: true},
+		{name: "index non list", expression: "5[0]", wantErr: true},
+		{name: "concat", expression: "tags + ['clearance']", data: data, want: "[new sale clearance]"},
+		{name: "equal", expression: "thresholds == [10, 20, 30]", data: data, want: "true"},
+		{name: "not equal", expression: "thresholds != [10, 20]", data: data, want: "true"},
+		{name: "equal mixed types", expression: "[1, 'a'] == [1, 2]", want: "false"},
+		{name: "truthiness empty", expression: "empty ? 'yes' : 'no'", data: data, want: "no"},
+		{name: "truthiness", expression: "tags ? 'yes' : 'no'", data: data, want: "yes"},
+	})
+}
+
+func TestEvalUnsupportedData(t *testing.T) {
+	_, err := Eval("x", map[string]interface{}{"x": struct{}{}})
+	if err == nil {
+		t.Error("Eval() expected error for unsupported data")
+	}
+}
+
+func TestEvalMap(t *testing.T) {
+	data := map[string]interface{}{
+		"user": map[string]interface{}{
+			"name": "anthosh",
+			"age":  24,
+			"address": map[string]interface{}{
+				"city": "Chennai",
+			},
+			"tags": []string{"admin"},
+		},
+		"scores": map[string]int{"math": 90},
+	}
+	runEvalTests(t, []evalTest{
+		{name: "dot access", expression: "user.age + 1", data: data, want: "25"},
+		{name: "nested dot access", expression: "user.address.city", data: data, want: "Chennai"},
+		{name: "bracket access", expression: "user['name']", data: data, want: "anthosh"},
+		{name: "typed go map", expression: "scores.math", data: data, want: "90"},
+		{name: "list in map", expression: "user.tags[0]", data: data, want: "admin"},
+		{name: "literal", expression: "{a: 1, 'b c': 2}['b c']", want: "2"},
+		{name: "empty literal", expression: "{} ? 1 : 0", want: "0"},
+		{name: "equal", expression: "{a: 1, b: [1]} == {b: [1], a: 1}", want: "true"},
+		{name: "missing key", expression: "user.salary", data: data, wantErr: true},
+		{name: "member of non map", expression: "user.age.value", data: data, wantErr: true},
+		{name: "non string key", expression: "user[1]", data: data, wantErr: true},
+	})
+}
+
+func TestEvalLambda(t *testing.T) {
+	data := map[string]interface{}{
+		"xs": []int{3, 1, 2},
+		"items": []interface{}{
+			map[string]interface{}{"name": "pen", "price": 5, "kind": "office"},
+			map[string]interface{}{"name": "lamp", "price": 40, "kind": "home"},
+			map[string]interface{}{"name": "desk", "price": 120, "kind": "office"},
+		},
+		"rate": 2,
+	}
+	runEvalTests(t, []evalTest{
+		{name: "map", expression: "map(xs, x => x * 2)", data: data, want: "[6 2 4]"},
+		{name: "map closure", expression: "map(xs, x => x * rate)", data: data, want: "[6 2 4]"},
+		{name: "filter", expression: "map(filter(items, i => i.price > 10), i => i.name)", data: data, want: "[lamp desk]"},
+		{name: "reduce", expression: "reduce(xs, (acc, x) => acc + x, 0)", data: data, want: "6"},
+		{name: "any", expression: "any(items, i => i.price > 100)", data: data, want: "true"},
+		{name: "all", expression: "all(items, i => i.price > 100)", data: data, want: "false"},
+		{name: "sortBy", expression: "sortBy(xs, x => x)", data: data, want: "[1 2 3]"},
+		{name: "sortBy string", expression: "map(sortBy(items, i => i.name), i => i.price)", data: data, want: "[120 40 5]"},
+		{name: "groupBy", expression: "map(groupBy(items, i => i.kind).office, i => i.name)", data: data, want: "[pen desk]"},
+		{name: "nested lambda", expression: "map(xs, x => map([1, 2], y => x * y))", data: data, want: "[[3 6] [1 2] [2 4]]"},
+		{name: "no params", expression: "map(xs, () => 1)", data: data, wantErr: true},
+		{name: "wrong arg type", expression: "map(xs, 1)", data: data, wantErr: true},
+		{name: "parameter is scoped", expression: "map(xs, x => x) + [x]", data: data, wantErr: true},
+	})
+}
+
+func TestEvalNull(t *testing.T) {
+	data := map[string]interface{}{
+		"user":     map[string]interface{}{"name": "chili", "manager": nil},
+		"nickname": nil,
+	}
+	runEvalTests(t, []evalTest{
+		{name: "literal", expression: "null", want: "<nil>"},
+		{name: "equal null", expression: "nickname == null", data: data, want: "true"},
+		{name: "not equal null", expression: "user.name != null", data: data, want: "true"},
+		{name: "coalesce", expression: "nickname ?? user.name", data: data, want: "chili"},
+		{name: "coalesce keeps falsy", expression: "0 ?? 1", want: "0"},
+		{name: "coalesce chain", expression: "null ?? nickname ?? 'guest'", data: data, want: "guest"},
+		{name: "optional chaining", expression: "user.manager?.name", data: data, want: "<nil>"},
+		{name: "optional chaining missing key", expression: "user?.age ?? 18", data: data, want: "18"},
+		{name: "optional chaining on non map", expression: "user.name?.first", data: data, wantErr: true},
+		{name: "arithmetic with null", expression: "nickname + 1", data: data, wantErr: true},
+		{name: "negate string", expression: "-user.name", data: data, wantErr: true},
+	})
+}
+
+func TestEvalTemplate(t *testing.T) {
+	data := map[string]interface{}{
+		"name":  "Anthosh",
+		"total": 41.6,
+		"tags":  []string{"a"},
+	}
+	runEvalTests(t, []evalTest{
+		{name: "interpolation", expression: "`Hello ${name}, you owe ${round(total)}`", data: data, want: "Hello Anthosh, you owe 42"},
+		{name: "no expression", expression: "`plain`", want: "plain"},
+		{name: "null and bool", expression: "`${null} ${1 > 2}`", want: "null false"},
+		{name: "nested string", expression: "`${concat(name, '!')}`", data: data, want: "Anthosh!"},
+		{name: "escapes", expression: "`\\${name}\\t`", data: data, want: "${name}\t"},
+		{name: "empty expression", expression: "`${}`", wantErr: true},
+		{name: "trailing tokens", expression: "`${name name}`", data: data, wantErr: true},
+		{name: "unterminated", expression: "`${name}", data: data, wantErr: true},
+	})
+}
+
+func TestEvalRegex(t *testing.T) {
+	data := map[string]interface{}{
+		"name":    "ACME-1042",
+		"pattern": "(",
+	}
+	runEvalTests(t, []evalTest{
+		{name: "match", expression: `name =~ '^ACME-\d+$'`, data: data, want: "true"},
+		{name: "not match", expression: `name !~ '^ACME-\d+$'`, data: data, want: "false"},
+		{name: "function", expression: `regexExtract(name, '\d+')`, data: data, want: "1042"},
+		{name: "invalid literal pattern", expression: `name =~ '('`, data: data, wantErr: true},
+		{name: "invalid literal pattern in function", expression: `regexMatch(name, '[')`, data: data, wantErr: true},
+		{name: "piped replacement is not pattern", expression: `'abc' |> regexReplace('b', '(')`, want: "a(c"},
+		{name: "invalid literal pattern in piped function", expression: `name |> regexMatch('[')`, data: data, wantErr: true},
+		{name: "invalid runtime pattern", expression: `name =~ pattern`, data: data, wantErr: true},
+		{name: "non string operand", expression: `1 =~ '1'`, wantErr: true},
+	})
+}
+
+func TestEvalLet(t *testing.T) {
+	data := map[string]interface{}{
+		"price":    10,
+		"qty":      3,
+		"discount": 0.5,
+	}
+	runEvalTests(t, []evalTest{
+		{name: "bindings", expression: "let subtotal = price * qty * (1 - discount), tax = subtotal * 0.2 in subtotal + tax", data: data, want: "18"},
+		{name: "shadowing", expression: "let price = 1 in price + qty", data: data, want: "4"},
+		{name: "nested", expression: "let a = 1 in let b = a + 1 in a + b", want: "3"},
+		{name: "lambda binding", expression: "let double = x => x * 2 in double(qty)", data: data, want: "6"},
+		{name: "scoped", expression: "(let a = 1 in a) + a", wantErr: true},
+		{name: "duplicate binding", expression: "let a = 1, a = 2 in a", wantErr: true},
+		{name: "earlier bindings", expression: "let k = 2, f = x => x * k in f(qty)", data: data, want: "6"},
+		{name: "later binding", expression: "let a = b, b = 1 in a", wantErr: true},
+		{name: "outer value", expression: "let price = price * 2 in price", data: data, want: "20"},
+		{name: "self reference", expression: "let f = x => f(x) in f(1)", wantErr: true},
+		{name: "missing in", expression: "let a = 1 a", wantErr: true},
+	})
+}
+
+func TestEvalPipe(t *testing.T) {
+	data := map[string]interface{}{
+		"s":  "-1,234.6",
+		"xs": []int{1, 2, 3, 4},
+	}
+	runEvalTests(t, []evalTest{
+		{name: "chain", expression: "s |> replaceAll(',', '') |> toNumber() |> abs() |> round()", data: data, want: "1235"},
+		{name: "bare function", expression: "-2.5 |> abs", want: "2.5"},
+		{name: "with lambda", expression: "xs |> filter(x => x % 2 == 0) |> map(x => x * 10)", data: data, want: "[20 40]"},
+		{name: "binds looser than addition", expression: "1 + 2 |> max(10)", want: "10"},
+		{name: "binds tighter than comparison", expression: "s |> length() > 3", data: data, want: "true"},
+		{name: "arity check", expression: "s |> replaceAll(',')", data: data, wantErr: true},
+		{name: "type check", expression: "1 |> length()", wantErr: true},
+		{name: "not a call", expression: "1 |> 2", wantErr: true},
+	})
+}
+
+func TestEvalErrors(t *testing.T) {
+	data := map[string]interface{}{"n": 10, "s": "x", "p": "("}
+	tests := []struct {
+		name       string
+		expression string
+		target     interface{}
+		position   string
+	}{
+		{name: "syntax", expression: "1 + * 2", target: new(*diagnostic.SyntaxError), position: "1:5"},
+		{name: "lexer", expression: "1 + 1.", target: new(*diagnostic.SyntaxError), position: "1:5"},
+		{name: "stray assignment", expression: "s = 'active'", target: new(*diagnostic.SyntaxError), position: "1:3"},
+		{name: "stray index assignment", expression: "[1, 2][0] = 2", target: new(*diagnostic.SyntaxError), position: "1:11"},
+		{name: "trailing token", expression: "n + 1 2", target: new(*diagnostic.SyntaxError), position: "1:7"},
+		{name: "type", expression: "n +\n  true", target: new(*diagnostic.TypeError), position: "1:1"},
+		{name: "undefined variable", expression: "n + m", target: new(*diagnostic.UndefinedSymbolError), position: "1:5"},
+		{name: "undefined function", expression: "1 + f(2)", target: new(*diagnostic.UndefinedSymbolError), position: "1:5"},
+		{name: "arity", expression: "n + abs(1, 2)", target: new(*diagnostic.ArityError), position: "1:5"},
+		{name: "division by zero", expression: "1 + n / (n - 10)", target: new(*diagnostic.DivisionByZeroError), position: "1:5"},
+		{name: "wrong argument type", expression: "abs(s)", target: new(*diagnostic.TypeError), position: "1:1"},
+		{name: "list index out of range", expression: "[1, 2][5]", target: new(*diagnostic.ValueError), position: "1:8"},
+		{name: "list index beyond int64", expression: "[1, 2][18446744073709551616]", target: new(*diagnostic.ValueError), position: "1:8"},
+		{name: "negative list index beyond int64", expression: "[1, 2][-18446744073709551617]", target: new(*diagnostic.ValueError), position: "1:8"},
+		{name: "fractional list index", expression: "n + [1, 2][0.5]", target: new(*diagnostic.TypeError), position: "1:12"},
+		{name: "unknown key", expression: "{a: 1}.b", target: new(*diagnostic.UndefinedSymbolError), position: "1:1"},
+		{name: "unknown index key", expression: "{a: 1}['b']", target: new(*diagnostic.UndefinedSymbolError), position: "1:8"},
+		{name: "runtime pattern", expression: "n > 1 && s =~ p", target: new(*diagnostic.ValueError), position: "1:10"},
+		{name: "runtime pattern in function", expression: "regexMatch(s, p)", target: new(*diagnostic.ValueError), position: "1:1"},
+		{name: "range too large", expression: "n + length(1..10000000)", target: new(*diagnostic.ValueError), position: "1:5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Eval(tt.expression, data)
+			if !errors.As(err, tt.target) {
+				t.Fatalf("Eval(%q) error = %v, want %T", tt.expression, err, tt.target)
+			}
+			var positioned diagnostic.Positioned
+			errors.As(err, &positioned)
+			if got := positioned.Base().Span.Start.String(); got != tt.position {
+				t.Errorf("Eval(%q) error position = %s, want %s", tt.expression, got, tt.position)
+			}
+		})
+	}
+}
+
+func TestEvalErrorsWrapSentinel(t *testing.T) {
+	_, err := Eval("1 % 0", nil)
+	if !errors.Is(err, evaluator.ErrDivisionByZero) {
+		t.Errorf("Eval() error = %v, want ErrDivisionByZero", err)
+	}
+	if err.Error() != evaluator.ErrDivisionByZero.Error() {
+		t.Errorf("Eval() error = %q, want %q", err.Error(), evaluator.ErrDivisionByZero.Error())
+	}
+}
+
+func TestEvalErrorPretty(t *testing.T) {
+	_, err := Eval("price * 2 +\n  max(price, 'x')", map[string]interface{}{"price": 2})
+	want := "2:3: max() argument 2 expects NUMBER but got STRING\n" +
+		" 2 |   max(price, 'x')\n" +
+		"   |   ^^^^^^^^^^^^^^^"
+	if got := diagnostic.Pretty(err); got != want {
+		t.Errorf("Pretty() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEvalPrecedence(t *testing.T) {
+	runEvalTests(t, []evalTest{
+		{name: "exponent is right associative", expression: "2 ^ 3 ^ 2", want: "512"},
+		{name: "exponent binds tighter than multiply", expression: "2 * 3 ^ 2", want: "18"},
+		{name: "lesser", expression: "1 < 1", want: "false"},
+		{name: "lesser equal", expression: "1 <= 1", want: "true"},
+		{name: "and binds tighter than or", expression: "true || true && false", want: "true"},
+		{name: "comparison binds tighter than equality", expression: "1 < 2 == 3 < 4", want: "true"},
+	})
+}
+
+func TestEvalComments(t *testing.T) {
+	data := map[string]interface{}{"score": 72, "vip": true}
+	rule := `score >= 90 ? 'gold'   # top tier
+	: score >= 70 /* vip bump */ && vip ? 'silver'
+	# everyone else
+	: 'bronze'`
+	runEvalTests(t, []evalTest{
+		{name: "annotated rule", expression: rule, data: data, want: "silver"},
+		{name: "division", expression: "10 / 2 # half", want: "5"},
+		{name: "block between operands", expression: "10 /* / 0 */ / 5", want: "2"},
+		{name: "only comment", expression: "# nothing", wantErr: true},
+		{name: "unterminated block", expression: "1 /* 2", wantErr: true},
+		{name: "slash line comment", expression: "// note\n1", wantErr: true},
+		{name: "slash after operator", expression: "1 + // note\n1", wantErr: true},
+	})
+}
+
+func TestEvalIntegerOperators(t *testing.T) {
+	data := map[string]interface{}{"perms": 0b0110, "READ": 0b0100, "WRITE": 0b0010, "EXEC": 0b0001}
+	runEvalTests(t, []evalTest{
+		{name: "floor division", expression: "7 // 2", want: "3"},
+		{name: "floor division negative", expression: "-7 // 2", want: "-4"},
+		{name: "floor division negative divisor", expression: "7 // -2", want: "-4"},
+		{name: "floor division exact", expression: "-6 // 3", want: "-2"},
+		{name: "floor division by zero", expression: "1 // 0", wantErr: true},
+		{name: "and", expression: "perms & READ == READ", data: data, want: "true"},
+		{name: "and missing", expression: "perms & EXEC == EXEC", data: data, want: "false"},
+		{name: "or", expression: "READ | WRITE | EXEC", data: data, want: "7"},
+		{name: "xor", expression: "perms xor WRITE", data: data, want: "4"},
+		{name: "not", expression: "~0", want: "-1"},
+		{name: "clear flag", expression: "perms & ~WRITE", data: data, want: "4"},
+		{name: "shift left", expression: "1 << 10", want: "1024"},
+		{name: "shift right", expression: "0xFF >> 4", want: "15"},
+		{name: "shift right negative", expression: "-8 >> 1", want: "-4"},
+		{name: "negative shift", expression: "1 << -1", wantErr: true},
+		{name: "fraction", expression: "1.5 & 1", wantErr: true},
+		{name: "fraction not", expression: "~1.5", wantErr: true},
+		{name: "fraction floor division", expression: "7.5 // 2", wantErr: true},
+		{name: "string", expression: "'a' | 1", wantErr: true},
+	})
+	_, err := Eval("perms | 0.5", data)
+	var typeErr *diagnostic.TypeError
+	if !errors.As(err, &typeErr) || typeErr.Span.Start.Column != 1 {
+		t.Errorf("Eval() error = %v, want positioned TypeError", err)
+	}
+}
+
+func TestEvalMembership(t *testing.T) {
+	data := map[string]interface{}{
+		"status": "b",
+		"x":      5,
+		"n":      9,
+		"tags":   []string{"new", "sale"},
+		"order":  map[string]interface{}{"id": 1},
+	}
+	runEvalTests(t, []evalTest{
+		{name: "tuple", expression: "status in ('a', 'b', 'c')", data: data, want: "true"},
+		{name: "list", expression: "'old' in tags", data: data, want: "false"},
+		{name: "not in", expression: "x not in [1, 2, 3]", data: data, want: "true"},
+		{name: "not", expression: "not (x in [5])", data: data, want: "false"},
+		{name: "substring", expression: "'ew' in 'new'", want: "true"},
+		{name: "map key", expression: "'id' in order", data: data, want: "true"},
+		{name: "range", expression: "x in 1..10", data: data, want: "true"},
+		{name: "range end", expression: "10 in 1..10", want: "true"},
+		{name: "exclusive range end", expression: "10 in 1..<10", want: "false"},
+		{name: "range expression", expression: "x in n-5..n+1", data: data, want: "true"},
+		{name: "range fraction", expression: "5.5 in 1..10", wantErr: true},
+		{name: "range fraction not in", expression: "5.5 not in 1..10", wantErr: true},
+		{name: "range integral decimal", expression: "5.0 in 1..10", want: "true"},
+		{name: "range function", expression: "map(1..3, x => x * 2)", want: "[2 4 6]"},
+		{name: "empty range", expression: "1..<1 ?? 'x'", want: "1..<1"},
+		{name: "range equal", expression: "1..3 == 1..3", want: "true"},
+		{name: "null in list", expression: "null in [1, null]", want: "true"},
+		{name: "let in", expression: "let a = 1 in a", want: "1"},
+		{name: "let membership", expression: "let a = (1 in [1]) in a", want: "true"},
+		{name: "fraction range", expression: "1.5..3", wantErr: true},
+		{name: "string range", expression: "'a'..'c'", wantErr: true},
+		{name: "number in string", expression: "1 in 'abc'", wantErr: true},
+		{name: "in null", expression: "1 in null", wantErr: true},
+		{name: "large range", expression: "map(1..10000000, x => x)", wantErr: true},
+		{name: "overflowing length", expression: "length(0..(2^64))", wantErr: true},
+		{name: "overflowing filter", expression: "filter(0..(2^64), x => x > 1)", wantErr: true},
+		{name: "overflowing membership", expression: "2^63 in 0..(2^64)", want: "true"},
+		{name: "reversed overflowing range", expression: "length((2^64)..0)", want: "0"},
+	})
+}
+
+func TestEvalCase(t *testing.T) {
+	data := map[string]interface{}{"score": 72, "status": "b", "items": []int{}}
+	runEvalTests(t, []evalTest{
+		{name: "searched", expression: "case when score >= 90 then 'A' when score >= 70 then 'B' else 'C' end", data: data, want: "B"},
+		{name: "else", expression: "case when score > 100 then 'X' else 'Y' end", data: data, want: "Y"},
+		{name: "no else", expression: "case when score > 100 then 'X' end", data: data, want: "<nil>"},
+		{name: "subject", expression: "case status when 'a' then 1 when 'b' then 2 end", data: data, want: "2"},
+		{name: "subject type mismatch", expression: "case status when 1 then 'one' else 'other' end", data: data, want: "other"},
+		{name: "null subject", expression: "case null when null then 'none' end", want: "none"},
+		{name: "lazy", expression: "case when true then 1 else 1 / 0 end", want: "1"},
+		{name: "lazy condition", expression: "case when true then 1 when 1 / 0 then 2 end", want: "1"},
+		{name: "nested", expression: "case when score > 50 then case when score > 70 then 'high' end end", data: data, want: "high"},
+		{name: "in", expression: "let s = case when status in ['a', 'b'] then 'ok' end in s", data: data, want: "ok"},
+		{name: "missing end", expression: "case when true then 1", wantErr: true},
+		{name: "missing when", expression: "case else 1 end", wantErr: true},
+	})
+}
+
+func TestEvalWordOperators(t *testing.T) {
+	data := map[string]interface{}{"age": 30, "member": false}
+	runEvalTests(t, []evalTest{
+		{name: "and", expression: "age > 18 and age < 65", data: data, want: "true"},
+		{name: "or", expression: "member or age > 60", data: data, want: "false"},
+		{name: "not", expression: "not member", data: data, want: "true"},
+		{name: "mixed", expression: "not member and age >= 30 || false", data: data, want: "true"},
+		{name: "not comparison", expression: "not age == 18", data: data, want: "true"},
+		{name: "not binds looser than comparison", expression: "not age > 18 or member", data: data, want: "false"},
+		{name: "not not", expression: "not not member", data: data, want: "false"},
+		{name: "double bang", expression: "!!member", data: data, want: "false"},
+		{name: "double minus", expression: "- -1", want: "1"},
+		{name: "precedence", expression: "true or false and false", want: "true"},
+		{name: "keyword data", expression: `@"and" + prop("end")`, data: map[string]interface{}{"and": 1, "end": 2}, want: "3"},
+		{name: "reserved let", expression: "let end = 1 in end", wantErr: true},
+	})
+	_, err := Eval("let or = 1 in or", nil)
+	var syntaxErr *diagnostic.SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Span.Start.Column != 5 {
+		t.Errorf("Eval() error = %v, want positioned SyntaxError", err)
+	}
+}
+
+func TestEvalDataShadowsBuiltins(t *testing.T) {
+	data := map[string]interface{}{"map": 1, "filter": 2, "any": 3, "prop": 4, "E": 5, "in": 6, "when": 7}
+	runEvalTests(t, []evalTest{
+		{name: "functions", expression: "map + filter + any + prop", data: data, want: "10"},
+		{name: "constant", expression: "E * 2", data: data, want: "10"},
+		{name: "keywords", expression: `@"in" + @"when"`, data: data, want: "13"},
+		{name: "other builtins", expression: "max(map, filter)", data: data, want: "2"},
+		{name: "shadowed call", expression: "map([1], x => x)", data: data, wantErr: true},
+		{name: "unshadowed call", expression: "map([1], x => x + 1)", want: "[2]"},
+	})
+}
+
+func TestEvalQuotedIdentifier(t *testing.T) {
+	data := map[string]interface{}{
+		"Unit Price": 2.5,
+		"Q1-Revenue": 100,
+		"order":      map[string]interface{}{"Ship Date": "today"},
+	}
+	runEvalTests(t, []evalTest{
+		{name: "double quotes", expression: `@"Unit Price" * 4`, data: data, want: "10"},
+		{name: "single quotes", expression: `@'Q1-Revenue' - 1`, data: data, want: "99"},
+		{name: "member", expression: `order.@"Ship Date"`, data: data, want: "today"},
+		{name: "let", expression: `let @"Net Price" = @"Unit Price" * 2 in @"Net Price"`, data: data, want: "5"},
+		{name: "lambda", expression: `map([1, 2], @"a b" => @"a b" + 1)`, want: "[2 3]"},
+		{name: "prop", expression: `prop("Unit Price") * prop("Q1-Revenue")`, data: data, want: "250"},
+		{name: "prop in scope", expression: `let x = 1 in prop("x")`, want: "1"},
+		{name: "prop unknown", expression: `prop("Unit Cost")`, data: data, wantErr: true},
+		{name: "unknown", expression: `@"Unit Cost"`, data: data, wantErr: true},
+		{name: "empty", expression: `@""`, wantErr: true},
+		{name: "unquoted", expression: `@Price`, wantErr: true},
+	})
+}
+
+func TestEvalNamedArguments(t *testing.T) {
+	data := map[string]interface{}{"price": 12.3456, "s": "banana"}
+	runEvalTests(t, []evalTest{
+		{name: "default", expression: "round(price)", data: data, want: "12"},
+		{name: "positional", expression: "round(price, 2)", data: data, want: "12.35"},
+		{name: "named", expression: "round(price, places: 2)", data: data, want: "12.35"},
+		{name: "all named", expression: "round(places: 1, number: price)", data: data, want: "12.3"},
+		{name: "piped", expression: "price |> round(places: 3)", data: data, want: "12.346"},
+		{name: "replace", expression: "replace(s, 'a', 'o', count: 2)", data: data, want: "bonona"},
+		{name: "reordered", expression: "replace(count: 1, replacement: 'A', search: 'a', text: s)", data: data, want: "bAnana"},
+		{name: "unknown", expression: "round(price, digits: 2)", data: data, wantErr: true},
+		{name: "duplicate", expression: "round(price, number: 2)", data: data, wantErr: true},
+		{name: "duplicate named", expression: "round(number: 1, number: 2)", wantErr: true},
+		{name: "missing required", expression: "round(places: 2)", wantErr: true},
+		{name: "missing argument", expression: "replace(s, 'a', count: 1)", data: data, wantErr: true},
+		{name: "positional after named", expression: "round(places: 2, price)", data: data, wantErr: true},
+		{name: "variadic", expression: "max(a: 1)", wantErr: true},
+		{name: "lambda", expression: "let f = x => x in f(x: 1)", wantErr: true},
+		{name: "ternary argument", expression: "round(s == 'banana' ? price : 0)", data: data, want: "12"},
+		{name: "fractional places", expression: "round(price, 1.7)", data: data, wantErr: true},
+		{name: "huge places", expression: "round(1, 1e10)", wantErr: true},
+	})
+	_, err := Eval("round(price, digits: 2)", data)
+	var arityErr *diagnostic.ArityError
+	if !errors.As(err, &arityErr) || arityErr.Name != "round" {
+		t.Errorf("Eval() error = %v, want ArityError of round", err)
+	}
+}
+
+func TestEvalSignatures(t *testing.T) {
+	data := map[string]interface{}{"tags": []string{"new", "sale", "hot"}}
+	runEvalTests(t, []evalTest{
+		{name: "length text", expression: "length('abc')", want: "3"},
+		{name: "length list", expression: "length(tags)", data: data, want: "3"},
+		{name: "length range", expression: "length(1..<5)", want: "4"},
+		{name: "join numbers", expression: "join('-', 'a', 1, 2.5)", want: "a-1-2.5"},
+		{name: "slice text", expression: "slice('chili', 1, 3)", want: "hi"},
+		{name: "slice list", expression: "slice(tags, 1, 10)", data: data, want: "[sale hot]"},
+		{name: "slice range", expression: "slice(1..10, 0, 2)", want: "[1 2]"},
+		{name: "slice bounds", expression: "slice('chili', 4, -1)", want: ""},
+		{name: "slice bounds beyond int64", expression: "slice(tags, 0, 18446744073709551616)", data: data, want: "[new sale hot]"},
+		{name: "slice text bounds beyond int64", expression: "slice('chili', -18446744073709551616, 18446744073709551617)", want: "chili"},
+		{name: "slice named", expression: "slice(tags, stop: 1, start: 0)", data: data, want: "[new]"},
+		{name: "join boolean", expression: "join('-', 'a', true)", wantErr: true},
+		{name: "slice boolean", expression: "slice(true, 0, 1)", wantErr: true},
+	})
+	_, err := Eval("slice(true, 0, 1)", nil)
+	want := "slice() has no signature for (BOOLEAN, NUMBER, NUMBER), candidates are slice(text STRING, start NUMBER, stop NUMBER), slice(list LIST, start NUMBER, stop NUMBER)"
+	if err == nil || err.Error() != want {
+		t.Errorf("Eval() error = %v, want %v", err, want)
+	}
+	_, err = Eval("join('-', 'a', true)", nil)
+	want = "join() argument 3 (values) expects STRING|NUMBER but got BOOLEAN"
+	if err == nil || err.Error() != want {
+		t.Errorf("Eval() error = %v, want %v", err, want)
+	}
+}
+
+func TestEvalSpread(t *testing.T) {
+	data := map[string]interface{}{
+		"scores": []int{3, 9, 4},
+		"names":  []string{"a", "b"},
+		"many":   make([]int, 300),
+		"empty":  []int{},
+	}
+	runEvalTests(t, []evalTest{
+		{name: "variadic", expression: "max(...scores)", data: data, want: "9"},
+		{name: "after positional", expression: "join(', ', ...names)", data: data, want: "a, b"},
+		{name: "mixed", expression: "max(1, ...scores, 10, ...scores)", data: data, want: "10"},
+		{name: "literal", expression: "min(...[5, 2, 8])", want: "2"},
+		{name: "range", expression: "max(...1..5)", want: "5"},
+		{name: "fixed arity", expression: "pow(...[2, 3])", want: "8"},
+		{name: "with named", expression: "round(...[1.256], places: 1)", want: "1.3"},
+		{name: "lambda", expression: "let f = (a, b) => a - b in f(...[5, 2])", want: "3"},
+		{name: "piped", expression: "2 |> max(...scores)", data: data, want: "9"},
+		{name: "empty", expression: "max(...empty)", data: data, wantErr: true},
+		{name: "arity", expression: "pow(...scores)", data: data, wantErr: true},
+		{name: "not list", expression: "max(...1)", wantErr: true},
+		{name: "too many", expression: "max(...many)", data: data, wantErr: true},
+		{name: "overflowing range", expression: "max(...(0..(2^64)))", wantErr: true},
+		{name: "named spread", expression: "round(number: ...[1])", wantErr: true},
+		{name: "outside call", expression: "[...names]", data: data, wantErr: true},
+	})
+	_, err := Eval("max(1, ...many)", data)
+	var arityErr *diagnostic.ArityError
+	if !errors.As(err, &arityErr) || arityErr.Span.Start.Column != 8 {
+		t.Errorf("Eval() error = %v, want ArityError at the spread", err)
+	}
+}
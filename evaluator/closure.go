@@ -0,0 +1,43 @@
+package evaluator
+
+import (
+	"fmt"
+
+	"github.com/5anthosh/chili/diagnostic"
+	"github.com/5anthosh/chili/environment"
+	"github.com/5anthosh/chili/parser/ast/expr"
+)
+
+//closure is the value of lambda expression, it captures the scope where lambda is created
+type closure struct {
+	lambda *expr.Lambda
+	env    *environment.Environment
+	eval   *Evaluator
+}
+
+//Call the lambda with arguments
+func (c *closure) Call(args []interface{}) (interface{}, error) {
+	if len(args) != len(c.lambda.Params) {
+		return nil, diagnostic.NewArityError(c.lambda.Location, "lambda", "lambda expecting %d arguments but got %d", len(c.lambda.Params), len(args))
+	}
+	scope := c.env.NewChild()
+	for i, param := range c.lambda.Params {
+		err := scope.DeclareVariable(param, args[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	//lambda has no name, empty name in call stack does not match any user defined function
+	maxCallDepth := c.eval.maxCallDepth()
+	if uint(len(c.eval.calls)) >= maxCallDepth {
+		return nil, diagnostic.NewRecursionError(c.lambda.Location, "lambda", "lambda exceeded maximum call depth %d", maxCallDepth)
+	}
+	c.eval.calls = append(c.eval.calls, "")
+	value, err := c.eval.runIn(scope, c.lambda.Body)
+	c.eval.calls = c.eval.calls[:len(c.eval.calls)-1]
+	return value, err
+}
+
+func (c *closure) String() string {
+	return fmt.Sprintf("lambda(%d)", len(c.lambda.Params))
+}
@@ -13,14 +13,23 @@ const (
 	BooleanType
 	GenerictType
 	NoneType
+	ListType
+	MapType
+	CallableType
+	RangeType
 	UnSupportedType
 )
 
+//Callable value like lambda which can be invoked by functions
+type Callable interface {
+	Call(args []interface{}) (interface{}, error)
+}
+
 //ErrUnknownDataype #
 var ErrUnknownDataype = errors.New("unknown datatype")
 
 var typeVsString = []string{
-	"NUMBER", "STRING", "BOOLEAN", "GENERIC", "NONE", "UNSUPPORTED",
+	"NUMBER", "STRING", "BOOLEAN", "GENERIC", "NONE", "LIST", "MAP", "FUNCTION", "RANGE", "UNSUPPORTED",
 }
 
 //Checkdatatype of value is correct
@@ -68,6 +77,36 @@ func CheckBoolean(values ...interface{}) bool {
 	return true
 }
 
+//CheckList checks whether values are list type
+func CheckList(values ...interface{}) bool {
+	for _, value := range values {
+		if !Checkdatatype(value, ListType) {
+			return false
+		}
+	}
+	return true
+}
+
+//CheckMap checks whether values are map type
+func CheckMap(values ...interface{}) bool {
+	for _, value := range values {
+		if !Checkdatatype(value, MapType) {
+			return false
+		}
+	}
+	return true
+}
+
+//CheckRange checks whether values are range type
+func CheckRange(values ...interface{}) bool {
+	for _, value := range values {
+		if !Checkdatatype(value, RangeType) {
+			return false
+		}
+	}
+	return true
+}
+
 //GetType of value
 func GetType(value interface{}) (uint, bool) {
 	switch value.(type) {
@@ -79,12 +118,54 @@ func GetType(value interface{}) (uint, bool) {
 		return BooleanType, true
 	case nil:
 		return NoneType, true
+	case []interface{}:
+		return ListType, true
+	case map[string]interface{}:
+		return MapType, true
+	case Callable:
+		return CallableType, true
+	case Range:
+		return RangeType, true
 	}
 	return UnSupportedType, false
 }
 
+//Truthy tells whether value is considered true in conditions
+func Truthy(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+	if CheckNumber(value) && value.(decimal.Decimal).Equals(decimal.Zero) {
+		return false
+	}
+	if CheckString(value) && len(value.(string)) == 0 {
+		return false
+	}
+	if CheckBoolean(value) {
+		return value.(bool)
+	}
+	if CheckList(value) && len(value.([]interface{})) == 0 {
+		return false
+	}
+	if CheckMap(value) && len(value.(map[string]interface{})) == 0 {
+		return false
+	}
+	if CheckRange(value) && value.(Range).Len() == 0 {
+		return false
+	}
+	return true
+}
+
 //GetTypeString #
 func GetTypeString(value interface{}) string {
 	dtype, _ := GetType(value)
+	return TypeString(dtype)
+}
+
+//TypeString is name of the datatype
+func TypeString(dtype uint) string {
+	if dtype < NumberType || dtype > UnSupportedType {
+		return typeVsString[UnSupportedType-1]
+	}
 	return typeVsString[int(dtype)-1]
 }
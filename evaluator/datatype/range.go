@@ -0,0 +1,81 @@
+package datatype
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/5anthosh/chili/diagnostic"
+	"github.com/5anthosh/chili/parser/token"
+
+	"github.com/shopspring/decimal"
+)
+
+//MaxRangeLength is the largest range which can be iterated
+const MaxRangeLength = 1000000
+
+//Range of integers from Start to End, End is excluded when Exclusive,
+//1..10 and 1..<10
+type Range struct {
+	Start     decimal.Decimal
+	End       decimal.Decimal
+	Exclusive bool
+}
+
+//Contains tells whether number is an integer between Start and End
+func (r Range) Contains(number decimal.Decimal) bool {
+	if !number.Equal(number.Truncate(0)) || number.LessThan(r.Start) {
+		return false
+	}
+	if r.Exclusive {
+		return number.LessThan(r.End)
+	}
+	return number.LessThanOrEqual(r.End)
+}
+
+//Equal tells whether both ranges cover the same integers in the same way
+func (r Range) Equal(other Range) bool {
+	return r.Exclusive == other.Exclusive && r.Start.Equal(other.Start) && r.End.Equal(other.End)
+}
+
+var maxInt64 = decimal.NewFromInt(math.MaxInt64)
+
+//Size is exact number of integers in the range, it does not overflow like Len
+func (r Range) Size() decimal.Decimal {
+	size := r.End.Sub(r.Start)
+	if !r.Exclusive {
+		size = size.Add(decimal.NewFromInt(1))
+	}
+	if size.Sign() < 0 {
+		return decimal.Zero
+	}
+	return size
+}
+
+//Len is number of integers in the range, it saturates at math.MaxInt64
+func (r Range) Len() int64 {
+	size := r.Size()
+	if size.GreaterThan(maxInt64) {
+		return math.MaxInt64
+	}
+	return size.IntPart()
+}
+
+//List of integers in the range
+func (r Range) List() ([]interface{}, error) {
+	if r.Size().GreaterThan(decimal.NewFromInt(MaxRangeLength)) {
+		return nil, diagnostic.NewValueError(token.Span{}, "range %s is too large to iterate, maximum length is %d", r.String(), MaxRangeLength)
+	}
+	length := r.Len()
+	list := make([]interface{}, 0, length)
+	for i := int64(0); i < length; i++ {
+		list = append(list, r.Start.Add(decimal.NewFromInt(i)))
+	}
+	return list, nil
+}
+
+func (r Range) String() string {
+	if r.Exclusive {
+		return fmt.Sprintf("%s..<%s", r.Start.String(), r.End.String())
+	}
+	return fmt.Sprintf("%s..%s", r.Start.String(), r.End.String())
+}
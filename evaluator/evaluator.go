@@ -3,10 +3,15 @@ package evaluator
 import (
 	"errors"
 	"fmt"
+	"strings"
 
+	"github.com/5anthosh/chili/diagnostic"
 	"github.com/5anthosh/chili/environment"
 	"github.com/5anthosh/chili/evaluator/datatype"
+	"github.com/5anthosh/chili/function"
+	"github.com/5anthosh/chili/operator"
 	"github.com/5anthosh/chili/parser/ast/expr"
+	"github.com/5anthosh/chili/parser/ast/stmt"
 	"github.com/5anthosh/chili/parser/token"
 	"github.com/shopspring/decimal"
 )
@@ -16,9 +21,55 @@ var (
 	ErrDivisionByZero = errors.New("decimal division by zero")
 )
 
+//Missing key policies for map member access
+const (
+	//MissingKeyError fails the evaluation when key is not in the map
+	MissingKeyError = iota
+	//MissingKeyNone evaluates missing key to NONE
+	MissingKeyNone
+)
+
+//Null policies for arithmetic and comparison operations with null operand,
+//equality (== and !=) is always defined and null is equal only to null
+const (
+	//NullError fails the evaluation when null is an operand
+	NullError = iota
+	//NullPropagate evaluates the operation to null like SQL
+	NullPropagate
+)
+
 //Evaluator #
 type Evaluator struct {
 	Env *environment.Environment
+	//MissingKey policy used when accessing a key which is not in the map
+	MissingKey uint
+	//Null policy used when operand of arithmetic or comparison operation is null
+	Null uint
+	//UndeclaredAsNull evaluates undeclared variables to null instead of failing
+	UndeclaredAsNull bool
+	//Recursion allows user defined functions to call themselves
+	Recursion bool
+	//MaxCallDepth of nested user defined function calls, DefaultMaxCallDepth when zero
+	MaxCallDepth uint
+	context      *function.Context
+	calls        []string
+}
+
+//DefaultMaxCallDepth of nested user defined function calls
+const DefaultMaxCallDepth = 64
+
+//userFunctionError is failure inside user defined function
+type userFunctionError struct {
+	name string
+	err  error
+}
+
+func (e *userFunctionError) Error() string {
+	return fmt.Sprintf("%s() failed: %v", e.name, e.err)
+}
+
+func (e *userFunctionError) Unwrap() error {
+	return e.err
 }
 
 //New Evaluator
@@ -26,9 +77,15 @@ func New(env *environment.Environment) *Evaluator {
 	if env == nil {
 		env = environment.New()
 	}
-	return &Evaluator{
-		Env: env,
-	}
+	eval := &Evaluator{Env: env}
+	eval.context = eval.newFunctionContext()
+	return eval
+}
+
+//ScriptResult is value of last statement and variables assigned by the script
+type ScriptResult struct {
+	Value    interface{}
+	Bindings map[string]interface{}
 }
 
 //Run the evaluator
@@ -36,6 +93,48 @@ func (eval *Evaluator) Run(expression expr.Expr) (interface{}, error) {
 	return eval.accept(expression)
 }
 
+//RunScript runs statements in a new scope of the environment
+func (eval *Evaluator) RunScript(statements []stmt.Stmt) (*ScriptResult, error) {
+	scope := eval.Env.NewChild()
+	env := eval.Env
+	eval.Env = scope
+	defer func() {
+		eval.Env = env
+	}()
+	var value interface{}
+	for _, statement := range statements {
+		var err error
+		value, err = statement.Accept(eval)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &ScriptResult{Value: value, Bindings: scope.Variables()}, nil
+}
+
+//VisitExpressionStmt #
+func (eval *Evaluator) VisitExpressionStmt(expressionStmt *stmt.Expression) (interface{}, error) {
+	return eval.accept(expressionStmt.Expression)
+}
+
+//VisitDefStmt #
+func (eval *Evaluator) VisitDefStmt(defStmt *stmt.Def) (interface{}, error) {
+	return nil, eval.Env.SetFunction(function.UserFunction(defStmt.Name, defStmt.Params, defStmt.Body))
+}
+
+//VisitAssignStmt #
+func (eval *Evaluator) VisitAssignStmt(assignStmt *stmt.Assign) (interface{}, error) {
+	value, err := eval.accept(assignStmt.Value)
+	if err != nil {
+		return nil, err
+	}
+	err = eval.Env.AssignVariable(assignStmt.Name, value)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
 //VisitBinaryExpr #
 func (eval *Evaluator) VisitBinaryExpr(binaryExpr *expr.Binary) (interface{}, error) {
 	left, err := eval.accept(binaryExpr.Left)
@@ -47,80 +146,30 @@ func (eval *Evaluator) VisitBinaryExpr(binaryExpr *expr.Binary) (interface{}, er
 		return nil, err
 	}
 
-	switch binaryExpr.Operator.Type.Type() {
-	case token.PlusType:
-		if datatype.CheckNumber(left, right) {
-			return left.(decimal.Decimal).Add(right.(decimal.Decimal)), nil
-		}
-		if datatype.CheckString(left) && datatype.CheckNumber(right) {
-			return left.(string) + (right.(decimal.Decimal)).String(), nil
-		}
-		if datatype.CheckNumber(left) && datatype.CheckString(right) {
-			return (left.(decimal.Decimal)).String() + right.(string), nil
-		}
-		return nil, generateUnsupportedOperationErr("+", left, right)
-	case token.MinusType:
-		if datatype.CheckNumber(left, right) {
-			return left.(decimal.Decimal).Sub(right.(decimal.Decimal)), nil
-		}
-		return nil, generateUnsupportedOperationErr("-", left, right)
-	case token.StarType:
-		if datatype.CheckNumber(left, right) {
-			return left.(decimal.Decimal).Mul(right.(decimal.Decimal)), nil
-		}
-		return nil, generateUnsupportedOperationErr("*", left, right)
-	case token.CommonSlashType:
-		if datatype.CheckNumber(left, right) {
-			if decimal.Zero.Equals(right.(decimal.Decimal)) {
-				return nil, ErrDivisionByZero
-			}
-			return left.(decimal.Decimal).Div(right.(decimal.Decimal)), nil
-		}
-		return nil, generateUnsupportedOperationErr("/", left, right)
-	case token.CapType:
-		if datatype.CheckNumber(left, right) {
-			return left.(decimal.Decimal).Pow(right.(decimal.Decimal)), nil
-		}
-		return nil, generateUnsupportedOperationErr("^", left, right)
-	case token.ModType:
-		if datatype.CheckNumber(left, right) {
-			if decimal.Zero.Equals(right.(decimal.Decimal)) {
-				return nil, ErrDivisionByZero
-			}
-			return left.(decimal.Decimal).Mod(right.(decimal.Decimal)), nil
-		}
-		return nil, generateUnsupportedOperationErr("%", left, right)
-	case token.EqualType:
-		return logicalOperation("==", left, right)
-	case token.NotEqualType:
-		value, err := logicalOperation("!=", left, right)
-		if err != nil {
-			return nil, err
-		}
-		return !value, nil
-	case token.GreaterType:
-		if datatype.CheckNumber(left, right) {
-			return left.(decimal.Decimal).GreaterThan(right.(decimal.Decimal)), nil
-		}
-		return nil, generateUnsupportedOperationErr(">", left, right)
-	case token.GreaterEqualType:
-		if datatype.CheckNumber(left, right) {
-			return left.(decimal.Decimal).GreaterThanOrEqual(right.(decimal.Decimal)), nil
-		}
-		return nil, generateUnsupportedOperationErr(">=", left, right)
-	case token.LesserType:
-		if datatype.CheckNumber(left, right) {
-			return left.(decimal.Decimal).LessThan(right.(decimal.Decimal)), nil
-		}
-		return nil, generateUnsupportedOperationErr("<", left, right)
-	case token.LesserEqualType:
-		if datatype.CheckNumber(left, right) {
-			return left.(decimal.Decimal).LessThanOrEqual(right.(decimal.Decimal)), nil
-		}
-		return nil, generateUnsupportedOperationErr("<=", left, right)
+	operatorType := binaryExpr.Operator.Type.Type()
+	if operatorType == token.OperatorType {
+		return eval.customOperation(binaryExpr.Operator, operator.Infix, left, right)
+	}
+	if eval.Null == NullPropagate && (left == nil || right == nil) && !isNullSafeOperator(operatorType) {
+		return nil, nil
+	}
+	operation, ok := binaryOperations[operatorType]
+	if !ok {
+		return nil, fmt.Errorf("Unexpected binary operator %s", binaryExpr.Operator.Type.String())
 	}
+	return operation(eval, binaryExpr.Operator, left, right)
+}
 
-	return nil, fmt.Errorf("Unexpected binary operator %s", binaryExpr.Operator.Type.String())
+//customOperation applies custom operator of the environment on operands
+func (eval *Evaluator) customOperation(t *token.Token, kind uint, operands ...interface{}) (interface{}, error) {
+	op, ok := eval.Env.Operators().Infix(t.Lexeme)
+	if kind == operator.Prefix {
+		op, ok = eval.Env.Operators().Prefix(t.Lexeme)
+	}
+	if !ok {
+		return nil, diagnostic.NewUndefinedSymbolError(t.Span, t.Lexeme, "Unknown operator %s", t.Lexeme)
+	}
+	return op.Impl(operands)
 }
 
 //VisitGroupExpr #
@@ -140,76 +189,239 @@ func (eval *Evaluator) VisitUnaryExpr(unaryExpr *expr.Unary) (interface{}, error
 		return nil, err
 	}
 
+	if unaryExpr.Operator.Type.Type() == token.OperatorType {
+		return eval.customOperation(unaryExpr.Operator, operator.Prefix, right)
+	}
 	if unaryExpr.Operator.Type.Type() == token.NotType {
-		return !truthFullness(right), nil
+		return !datatype.Truthy(right), nil
 	}
 
+	if right == nil && eval.Null == NullPropagate {
+		return nil, nil
+	}
+	if !datatype.CheckNumber(right) {
+		return nil, diagnostic.NewTypeError(
+			unaryExpr.Location,
+			"%s operation on %s is not supported",
+			unaryExpr.Operator.Lexeme,
+			datatype.GetTypeString(right),
+		)
+	}
 	if unaryExpr.Operator.Type.Type() == token.MinusType {
 		return (right.(decimal.Decimal)).Neg(), nil
 	}
+	if unaryExpr.Operator.Type.Type() == token.BitNotType {
+		value, err := toInteger(unaryExpr.Operator.Lexeme, right.(decimal.Decimal))
+		if err != nil {
+			return nil, err
+		}
+		return decimal.NewFromBigInt(value.Not(value), 0), nil
+	}
 	return right, nil
 }
 
 //VisitVariableExpr #
 func (eval *Evaluator) VisitVariableExpr(variableExpr *expr.Variable) (interface{}, error) {
-	ok := eval.Env.CheckSymbolTable(variableExpr.Name)
+	return eval.variable(variableExpr.Name, variableExpr.Location)
+}
+
+//variable resolves name in the current scope, it is shared by variable expressions and prop()
+func (eval *Evaluator) variable(name string, span token.Span) (interface{}, error) {
+	ok := eval.Env.IsDeclared(name)
 	if !ok {
-		return nil, fmt.Errorf("Unknown variable %s", variableExpr.Name)
+		if eval.UndeclaredAsNull {
+			return nil, nil
+		}
+		return nil, diagnostic.NewUndefinedSymbolError(span, name, "Unknown variable %s", name)
 	}
 
-	ok = eval.Env.IsVariable(variableExpr.Name)
+	ok = eval.Env.IsVariable(name)
 	if !ok {
-		return nil, fmt.Errorf("%s is not variable", variableExpr.Name)
+		return nil, diagnostic.NewTypeError(span, "%s is not variable", name)
 	}
 
-	value, ok := eval.Env.GetVariable(variableExpr.Name)
+	value, ok := eval.Env.GetVariable(name)
 	if ok {
 		return value, nil
 	}
 
-	return nil, fmt.Errorf("Unknown variable %s", variableExpr.Name)
+	return nil, diagnostic.NewUndefinedSymbolError(span, name, "Unknown variable %s", name)
 }
 
 //VisitFunctionCall #
 func (eval *Evaluator) VisitFunctionCall(functionCall *expr.FunctionCall) (interface{}, error) {
-	ok := eval.Env.CheckSymbolTable(functionCall.Name)
+	ok := eval.Env.IsDeclared(functionCall.Name)
 	if !ok {
-		return nil, fmt.Errorf("Unknown function %s()", functionCall.Name)
+		return nil, diagnostic.NewUndefinedSymbolError(functionCall.Location, functionCall.Name, "Unknown function %s()", functionCall.Name)
+	}
+
+	args, names, err := eval.arguments(functionCall)
+	if err != nil {
+		return nil, err
 	}
 
 	ok = eval.Env.IsFunction(functionCall.Name)
 	if !ok {
-		return nil, fmt.Errorf("%s is not function", functionCall.Name)
+		value, _ := eval.Env.GetVariable(functionCall.Name)
+		if callable, isCallable := value.(datatype.Callable); isCallable {
+			if names != nil {
+				return nil, diagnostic.NewArityError(functionCall.Location, functionCall.Name, "%s does not accept named arguments", functionCall.Name)
+			}
+			return callable.Call(args)
+		}
+		return nil, diagnostic.NewTypeError(functionCall.Location, "%s is not function", functionCall.Name)
 	}
 	_function, _ := eval.Env.GetFunction(functionCall.Name)
 
-	var args []interface{}
-	for _, arg := range functionCall.Args {
-		value, err := eval.accept(arg)
+	args, err = _function.BindArgs(args, names)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, args, err := _function.Resolve(args)
+	if err != nil {
+		return nil, err
+	}
+
+	if _function.VerifyArgs != nil {
+		err = _function.VerifyArgs(args)
 		if err != nil {
 			return nil, err
 		}
-		args = append(args, value)
 	}
 
-	err := _function.CheckNumberOfArgs(args)
+	if _function.IsUserFunction() {
+		return eval.callUserFunction(functionCall, _function, args)
+	}
+	if signature.ContextFunctionImpl != nil {
+		return signature.ContextFunctionImpl(eval.functionContext(), args)
+	}
+	return signature.FunctionImpl(args)
+}
+
+//arguments of the call, spread list is expanded into positional arguments and
+//names are aligned with the expanded arguments
+func (eval *Evaluator) arguments(functionCall *expr.FunctionCall) ([]interface{}, []string, error) {
+	var args []interface{}
+	var names []string
+	for i, arg := range functionCall.Args {
+		name := ""
+		if functionCall.Names != nil {
+			name = functionCall.Names[i]
+		}
+		spread, ok := arg.(*expr.Spread)
+		if !ok {
+			value, err := eval.accept(arg)
+			if err != nil {
+				return nil, nil, err
+			}
+			args = append(args, value)
+			names = append(names, name)
+			continue
+		}
+		items, err := eval.spread(functionCall.Name, spread, len(args))
+		if err != nil {
+			return nil, nil, err
+		}
+		args = append(args, items...)
+		names = append(names, make([]string, len(items))...)
+	}
+	if len(args) > function.MaximumNumberOfParamsLimit {
+		return nil, nil, diagnostic.NewArityError(functionCall.Location, functionCall.Name, "%s() got %d arguments, maximum is %d", functionCall.Name, len(args), function.MaximumNumberOfParamsLimit)
+	}
+	if functionCall.Names == nil {
+		names = nil
+	}
+	return args, names, nil
+}
+
+//spread evaluates ...list argument of function name into items, count is number of arguments before it
+func (eval *Evaluator) spread(name string, spreadExpr *expr.Spread, count int) ([]interface{}, error) {
+	value, err := eval.accept(spreadExpr.Expression)
 	if err != nil {
 		return nil, err
 	}
+	var length decimal.Decimal
+	switch value.(type) {
+	case []interface{}:
+		length = decimal.NewFromInt(int64(len(value.([]interface{}))))
+	case datatype.Range:
+		length = value.(datatype.Range).Size()
+	default:
+		return nil, diagnostic.NewTypeError(spreadExpr.Location, "Cannot spread %s, expecting LIST or RANGE", datatype.GetTypeString(value))
+	}
+	if length.Add(decimal.NewFromInt(int64(count))).GreaterThan(decimal.NewFromInt(function.MaximumNumberOfParamsLimit)) {
+		return nil, diagnostic.NewArityError(spreadExpr.Location, name, "Spreading %s items into %s() exceeds maximum %d arguments", length.String(), name, function.MaximumNumberOfParamsLimit)
+	}
+	if r, ok := value.(datatype.Range); ok {
+		return r.List()
+	}
+	return value.([]interface{}), nil
+}
 
-	ok = _function.CheckTypeOfArgs(args)
-	if !ok {
-		return nil, fmt.Errorf("function %s() got wrong data type params", functionCall.Name)
+//VisitSpreadExpr is reached only when spread is not argument of function call
+func (eval *Evaluator) VisitSpreadExpr(spreadExpr *expr.Spread) (interface{}, error) {
+	return nil, diagnostic.NewSyntaxError(spreadExpr.Location, "Spread '...' is allowed only in function arguments")
+}
+
+func (eval *Evaluator) callUserFunction(functionCall *expr.FunctionCall, userFunction function.Function, args []interface{}) (interface{}, error) {
+	if !eval.Recursion {
+		for _, name := range eval.calls {
+			if name == userFunction.Name {
+				return nil, diagnostic.NewRecursionError(functionCall.Location, userFunction.Name, "recursive call to %s() is not allowed", userFunction.Name)
+			}
+		}
+	}
+	maxCallDepth := eval.maxCallDepth()
+	if uint(len(eval.calls)) >= maxCallDepth {
+		return nil, diagnostic.NewRecursionError(functionCall.Location, userFunction.Name, "%s() exceeded maximum call depth %d", userFunction.Name, maxCallDepth)
 	}
 
-	if _function.VerifyArgs != nil {
-		err = _function.VerifyArgs(args)
+	scope := eval.Env.Scope(userFunction.Name).NewChild()
+	for i, param := range userFunction.Params {
+		err := scope.DeclareVariable(param, args[i])
 		if err != nil {
 			return nil, err
 		}
 	}
+	eval.calls = append(eval.calls, userFunction.Name)
+	value, err := eval.runIn(scope, userFunction.Body)
+	eval.calls = eval.calls[:len(eval.calls)-1]
+	if err != nil {
+		if _, ok := err.(*userFunctionError); ok {
+			return nil, err
+		}
+		if userFunction.Source != "" {
+			err = diagnostic.WithSource(err, userFunction.Source)
+		}
+		return nil, &userFunctionError{name: userFunction.Name, err: err}
+	}
+	return value, nil
+}
+
+//maxCallDepth of nested calls of user defined functions and lambdas
+func (eval *Evaluator) maxCallDepth() uint {
+	if eval.MaxCallDepth == 0 {
+		return DefaultMaxCallDepth
+	}
+	return eval.MaxCallDepth
+}
 
-	return _function.FunctionImpl(args)
+func (eval *Evaluator) newFunctionContext() *function.Context {
+	return &function.Context{
+		Regex: function.NewRegexCache(),
+		Variable: func(name string) (interface{}, error) {
+			return eval.variable(name, token.Span{})
+		},
+	}
+}
+
+//functionContext shared with functions, created when evaluator is not made by New
+func (eval *Evaluator) functionContext() *function.Context {
+	if eval.context == nil {
+		eval.context = eval.newFunctionContext()
+	}
+	return eval.context
 }
 
 //VisitTernary #
@@ -218,7 +430,7 @@ func (eval *Evaluator) VisitTernary(ternaryExpr *expr.Ternary) (interface{}, err
 	if err != nil {
 		return nil, err
 	}
-	ok := truthFullness(cond)
+	ok := datatype.Truthy(cond)
 	if ok {
 		return eval.accept(ternaryExpr.True)
 	}
@@ -231,7 +443,7 @@ func (eval *Evaluator) VisitLogicalExpr(logicalExpr *expr.Logical) (interface{},
 	if err != nil {
 		return nil, err
 	}
-	ok := truthFullness(left)
+	ok := datatype.Truthy(left)
 	switch logicalExpr.Operator.Type.Type() {
 	case token.AndType:
 		if !ok {
@@ -241,7 +453,7 @@ func (eval *Evaluator) VisitLogicalExpr(logicalExpr *expr.Logical) (interface{},
 		if err != nil {
 			return nil, err
 		}
-		return truthFullness(right), nil
+		return datatype.Truthy(right), nil
 	case token.OrType:
 		if ok {
 			return true, nil
@@ -250,44 +462,242 @@ func (eval *Evaluator) VisitLogicalExpr(logicalExpr *expr.Logical) (interface{},
 		if err != nil {
 			return nil, err
 		}
-		return truthFullness(right), nil
+		return datatype.Truthy(right), nil
+	case token.NullCoalesceType:
+		if left != nil {
+			return left, nil
+		}
+		return eval.accept(logicalExpr.Right)
 	}
 	return nil, fmt.Errorf("Unexpected logical operator %s", logicalExpr.Operator.Type.String())
 
 }
-func (eval *Evaluator) accept(expr expr.Expr) (interface{}, error) {
-	return expr.Accept(eval)
+
+//VisitListExpr #
+func (eval *Evaluator) VisitListExpr(listExpr *expr.List) (interface{}, error) {
+	list := make([]interface{}, 0, len(listExpr.Elements))
+	for _, element := range listExpr.Elements {
+		value, err := eval.accept(element)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+	}
+	return list, nil
+}
+
+//VisitIndexExpr #
+func (eval *Evaluator) VisitIndexExpr(indexExpr *expr.Index) (interface{}, error) {
+	object, err := eval.accept(indexExpr.Object)
+	if err != nil {
+		return nil, err
+	}
+	index, err := eval.accept(indexExpr.Index)
+	if err != nil {
+		return nil, err
+	}
+	if datatype.CheckMap(object) {
+		if !datatype.CheckString(index) {
+			return nil, diagnostic.NewTypeError(indexExpr.Index.Span(), "map key must be STRING but got %s", datatype.GetTypeString(index))
+		}
+		return eval.mapValue(object.(map[string]interface{}), index.(string), indexExpr.Index.Span())
+	}
+	if !datatype.CheckList(object) {
+		return nil, diagnostic.NewTypeError(indexExpr.Object.Span(), "cannot index %s value", datatype.GetTypeString(object))
+	}
+	if !datatype.CheckNumber(index) {
+		return nil, diagnostic.NewTypeError(indexExpr.Index.Span(), "list index must be NUMBER but got %s", datatype.GetTypeString(index))
+	}
+	list := object.([]interface{})
+	position, err := listPosition(index.(decimal.Decimal), len(list), indexExpr.Index.Span())
+	if err != nil {
+		return nil, err
+	}
+	return list[position], nil
 }
 
-func generateUnsupportedOperationErr(op string, left interface{}, right interface{}) error {
-	return fmt.Errorf("%s operation between (%s, %s) is not supported", op, datatype.GetTypeString(left), datatype.GetTypeString(right))
+//VisitMapExpr #
+func (eval *Evaluator) VisitMapExpr(mapExpr *expr.Map) (interface{}, error) {
+	value := make(map[string]interface{}, len(mapExpr.Keys))
+	for i, key := range mapExpr.Keys {
+		v, err := eval.accept(mapExpr.Values[i])
+		if err != nil {
+			return nil, err
+		}
+		value[key] = v
+	}
+	return value, nil
 }
 
-func truthFullness(value interface{}) bool {
-	if value == nil {
-		return false
+//VisitMemberExpr #
+func (eval *Evaluator) VisitMemberExpr(memberExpr *expr.Member) (interface{}, error) {
+	object, err := eval.accept(memberExpr.Object)
+	if err != nil {
+		return nil, err
+	}
+	if memberExpr.Optional && object == nil {
+		return nil, nil
 	}
-	if datatype.CheckNumber(value) && value.(decimal.Decimal).Equals(decimal.Zero) {
-		return false
+	if !datatype.CheckMap(object) {
+		return nil, diagnostic.NewTypeError(
+			memberExpr.Location,
+			"cannot access field %s of %s value",
+			memberExpr.Name,
+			datatype.GetTypeString(object),
+		)
 	}
-	if datatype.CheckString(value) && len(value.(string)) == 0 {
-		return false
+	if memberExpr.Optional {
+		return object.(map[string]interface{})[memberExpr.Name], nil
 	}
-	if datatype.CheckBoolean(value) {
-		return value.(bool)
+	return eval.mapValue(object.(map[string]interface{}), memberExpr.Name, memberExpr.Location)
+}
+
+//mapValue of key, span is location of the key in the source
+func (eval *Evaluator) mapValue(object map[string]interface{}, key string, span token.Span) (interface{}, error) {
+	value, ok := object[key]
+	if ok {
+		return value, nil
+	}
+	if eval.MissingKey == MissingKeyNone {
+		return nil, nil
+	}
+	return nil, diagnostic.NewUndefinedSymbolError(span, key, "Unknown key %s", key)
+}
+
+//VisitLambdaExpr #
+func (eval *Evaluator) VisitLambdaExpr(lambdaExpr *expr.Lambda) (interface{}, error) {
+	return &closure{lambda: lambdaExpr, env: eval.Env, eval: eval}, nil
+}
+
+//VisitTemplateExpr #
+func (eval *Evaluator) VisitTemplateExpr(templateExpr *expr.Template) (interface{}, error) {
+	var builder strings.Builder
+	for _, part := range templateExpr.Parts {
+		value, err := eval.accept(part)
+		if err != nil {
+			return nil, err
+		}
+		builder.WriteString(toString(value))
+	}
+	return builder.String(), nil
+}
+
+//VisitLetExpr #
+func (eval *Evaluator) VisitLetExpr(letExpr *expr.Let) (interface{}, error) {
+	//each binding gets its own scope so that value is evaluated with only the earlier
+	//bindings, lambda bound by let does not capture a scope which contains itself
+	scope := eval.Env
+	for i, name := range letExpr.Names {
+		for _, previous := range letExpr.Names[:i] {
+			if previous == name {
+				return nil, fmt.Errorf("%s is already declared", name)
+			}
+		}
+		value, err := eval.runIn(scope, letExpr.Values[i])
+		if err != nil {
+			return nil, err
+		}
+		scope = scope.NewChild()
+		err = scope.DeclareVariable(name, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return eval.runIn(scope, letExpr.Body)
+}
+
+//VisitBadExpr #
+func (eval *Evaluator) VisitBadExpr(badExpr *expr.Bad) (interface{}, error) {
+	return nil, diagnostic.NewSyntaxError(badExpr.Location, "Invalid expression")
+}
+
+//VisitCaseExpr evaluates conditions in order till one matches, only the taken
+//branch is evaluated, null when nothing matches and there is no else
+func (eval *Evaluator) VisitCaseExpr(caseExpr *expr.Case) (interface{}, error) {
+	var subject interface{}
+	if caseExpr.Subject != nil {
+		var err error
+		subject, err = eval.accept(caseExpr.Subject)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for i, condition := range caseExpr.Conditions {
+		value, err := eval.accept(condition)
+		if err != nil {
+			return nil, err
+		}
+		matched := datatype.Truthy(value)
+		if caseExpr.Subject != nil {
+			matched, err = sameTypeEqual("case", subject, value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if matched {
+			return eval.accept(caseExpr.Values[i])
+		}
+	}
+	if caseExpr.Else == nil {
+		return nil, nil
+	}
+	return eval.accept(caseExpr.Else)
+}
+
+//runIn evaluates expression with scope as the environment
+func (eval *Evaluator) runIn(scope *environment.Environment, expression expr.Expr) (interface{}, error) {
+	env := eval.Env
+	eval.Env = scope
+	defer func() {
+		eval.Env = env
+	}()
+	return eval.accept(expression)
+}
+
+//accept evaluates expression, positioned errors without span are located at the expression
+func (eval *Evaluator) accept(expr expr.Expr) (interface{}, error) {
+	value, err := expr.Accept(eval)
+	if err != nil {
+		return nil, diagnostic.Locate(err, expr.Span())
+	}
+	return value, nil
+}
+
+//toString formats value for template strings
+func toString(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return value.(string)
+	case decimal.Decimal:
+		return value.(decimal.Decimal).String()
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+//isNullSafeOperator tells whether operator is defined for null operands under every null policy
+func isNullSafeOperator(operatorType uint) bool {
+	switch operatorType {
+	case token.EqualType, token.NotEqualType, token.InType, token.NotInType:
+		return true
 	}
-	return true
+	return false
 }
 
-func logicalOperation(op string, left interface{}, right interface{}) (bool, error) {
-	if datatype.CheckNumber(left, right) {
-		return left.(decimal.Decimal).Equals(right.(decimal.Decimal)), nil
+//listPosition resolves index against a list of given length, negative index counts from the end,
+//span is location of the index in the source
+func listPosition(index decimal.Decimal, length int, span token.Span) (int, error) {
+	if !index.Equal(index.Truncate(0)) {
+		return 0, diagnostic.NewTypeError(span, "list index %s is not an integer", index.String())
 	}
-	if datatype.CheckString(left, right) {
-		return left.(string) == right.(string), nil
+	//range is checked in decimal since IntPart wraps for indices beyond int64
+	position := index
+	if position.IsNegative() {
+		position = position.Add(decimal.NewFromInt(int64(length)))
 	}
-	if datatype.CheckBoolean(left, right) {
-		return left.(bool) == right.(bool), nil
+	if position.IsNegative() || position.GreaterThanOrEqual(decimal.NewFromInt(int64(length))) {
+		return 0, diagnostic.NewValueError(span, "list index %s out of range", index.String())
 	}
-	return false, generateUnsupportedOperationErr(op, left, right)
+	return int(position.IntPart()), nil
 }
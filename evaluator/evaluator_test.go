@@ -1 +1,251 @@
 package evaluator
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/5anthosh/chili/diagnostic"
+	"github.com/5anthosh/chili/environment"
+	"github.com/5anthosh/chili/evaluator/datatype"
+	"github.com/5anthosh/chili/operator"
+	"github.com/5anthosh/chili/parser"
+	"github.com/shopspring/decimal"
+)
+
+func run(t *testing.T, eval *Evaluator, source string) (interface{}, error) {
+	expression, err := parser.NewWithOptions(source, parser.Options{Operators: eval.Env.Operators()}).Parse()
+	if err != nil {
+		t.Fatalf("Parse(%q) error = %v", source, err)
+	}
+	return eval.Run(expression)
+}
+
+func TestEvaluator_MissingKey(t *testing.T) {
+	env := environment.New()
+	env.DeclareVariable("user", map[string]interface{}{"name": "chili"})
+	eval := New(env)
+	if _, err := run(t, eval, "user.age"); err == nil {
+		t.Errorf("Evaluator.Run() expected error for missing key")
+	}
+	eval.MissingKey = MissingKeyNone
+	value, err := run(t, eval, "user['age']")
+	if err != nil {
+		t.Errorf("Evaluator.Run() error = %v", err)
+	}
+	if value != nil {
+		t.Errorf("Evaluator.Run() = %v, want nil", value)
+	}
+}
+
+func TestEvaluator_NullPolicy(t *testing.T) {
+	eval := New(environment.New())
+	eval.UndeclaredAsNull = true
+	tests := []struct {
+		source  string
+		null    uint
+		want    interface{}
+		wantErr bool
+	}{
+		{source: "missing", null: NullError, want: nil},
+		{source: "missing == null", null: NullError, want: true},
+		{source: "missing + 1", null: NullError, wantErr: true},
+		{source: "missing > 1", null: NullError, wantErr: true},
+		{source: "missing + 1", null: NullPropagate, want: nil},
+		{source: "-missing", null: NullPropagate, want: nil},
+		{source: "1 <= missing", null: NullPropagate, want: nil},
+		{source: "missing != 1", null: NullPropagate, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			eval.Null = tt.null
+			got, err := run(t, eval, tt.source)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Evaluator.Run() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("Evaluator.Run() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluator_RunScript(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		want     interface{}
+		bindings []string
+		wantErr  bool
+	}{
+		{name: "semicolons", source: "a = x * 2; b = a + y; b > 10", want: true, bindings: []string{"a", "b"}},
+		{name: "newlines", source: "a = x * 2\nb = a +\n y\nb", want: "13", bindings: []string{"a", "b"}},
+		{name: "reassign", source: "a = 1; a = a + x; a", want: "6", bindings: []string{"a"}},
+		{name: "shadow outer variable", source: "x = x + 1", want: "6", bindings: []string{"x"}},
+		{name: "empty", source: " ; ", want: nil},
+		{name: "newline before minus", source: "x = 5\na = 1\n-x", want: "-5", bindings: []string{"x", "a"}},
+		{name: "newline before paren", source: "a = y\n(1 + 2)", want: "3", bindings: []string{"a"}},
+		{name: "newline before bracket", source: "a = y\n[1, 2]", want: "[1 2]", bindings: []string{"a"}},
+		{name: "newline inside brackets", source: "a = max(1,\n  2\n  - 3)\n-a", want: "-1", bindings: []string{"a"}},
+		{name: "leading binary operator", source: "a = x\n* 2", wantErr: true},
+		{name: "missing separator", source: "a = 1 b = 2", wantErr: true},
+		{name: "assign to function", source: "abs = 1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := environment.New()
+			env.SetDefaultFunctions()
+			env.SetIntVariable("x", 5)
+			env.SetIntVariable("y", 3)
+			statements, err := parser.New(tt.source).ParseScript()
+			if err == nil {
+				var result *ScriptResult
+				result, err = New(env).RunScript(statements)
+				if err == nil {
+					if fmt.Sprintf("%v", result.Value) != fmt.Sprintf("%v", tt.want) {
+						t.Errorf("Evaluator.RunScript().Value = %v, want %v", result.Value, tt.want)
+					}
+					if len(result.Bindings) != len(tt.bindings) {
+						t.Errorf("Evaluator.RunScript().Bindings = %v, want %v", result.Bindings, tt.bindings)
+					}
+					for _, name := range tt.bindings {
+						if _, ok := result.Bindings[name]; !ok {
+							t.Errorf("Evaluator.RunScript().Bindings missing %s", name)
+						}
+					}
+				}
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Evaluator.RunScript() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEvaluator_UserFunction(t *testing.T) {
+	tests := []struct {
+		name      string
+		source    string
+		recursion bool
+		want      string
+		wantErr   string
+	}{
+		{name: "call", source: "def discount(p, rate) = p * (1 - rate)\ndiscount(200, 0.25)", want: "150"},
+		{name: "lexical scope", source: "def addRate(p) = p + rate\nlet rate = 100 in addRate(1)", wantErr: "addRate() failed: Unknown variable rate"},
+		{name: "uses globals", source: "def area(r) = PI * r ^ 2\nround(area(1))", want: "3"},
+		{name: "arity", source: "def twice(x) = x * 2; twice(1, 2)", wantErr: "twice() expecting 1 arguments but got 2"},
+		{name: "named arguments", source: "def discount(p, rate) = p * (1 - rate)\ndiscount(rate: 0.5, p: 10)", want: "5"},
+		{name: "missing argument", source: "def discount(p, rate) = p * (1 - rate)\ndiscount(rate: 0.5)", wantErr: "discount() missing argument p"},
+		{name: "recursion disabled", source: "def fact(n) = n <= 1 ? 1 : n * fact(n - 1); fact(5)", wantErr: "fact() failed: recursive call to fact() is not allowed"},
+		{name: "recursion", source: "def fact(n) = n <= 1 ? 1 : n * fact(n - 1); fact(5)", recursion: true, want: "120"},
+		{name: "recursion depth", source: "def loop(n) = loop(n + 1); loop(0)", recursion: true, wantErr: "loop() failed: loop() exceeded maximum call depth 10"},
+		{name: "lambda recursion depth", source: "f = x => f(x); f(1)", wantErr: "lambda exceeded maximum call depth 10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := environment.New()
+			env.SetDefaultFunctions()
+			env.SetDefaultVariables()
+			statements, err := parser.New(tt.source).ParseScript()
+			if err != nil {
+				t.Fatalf("ParseScript() error = %v", err)
+			}
+			eval := New(env)
+			eval.Recursion = tt.recursion
+			eval.MaxCallDepth = 10
+			result, err := eval.RunScript(statements)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Evaluator.RunScript() error = %v, want %v", err, tt.wantErr)
+				}
+				var positioned diagnostic.Positioned
+				if !errors.As(err, &positioned) || !positioned.Base().Located() {
+					t.Errorf("Evaluator.RunScript() error = %v, want located diagnostic", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("Evaluator.RunScript() error = %v", err)
+				return
+			}
+			if fmt.Sprintf("%v", result.Value) != tt.want {
+				t.Errorf("Evaluator.RunScript().Value = %v, want %v", result.Value, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluator_UserFunctionErrorSource(t *testing.T) {
+	env := environment.New()
+	env.SetDefaultFunctions()
+	if err := env.DefineFunction("def ratio(a, b) = a / b"); err != nil {
+		t.Fatalf("Environment.DefineFunction() error = %v", err)
+	}
+	source := "ratio(1, 0) + 1"
+	_, err := run(t, New(env), source)
+	err = diagnostic.WithSource(err, source)
+	want := "1:19: ratio() failed: decimal division by zero\n" +
+		" 1 | def ratio(a, b) = a / b\n" +
+		"   |                   ^^^^^"
+	if got := diagnostic.Pretty(err); got != want {
+		t.Errorf("Pretty() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestEvaluator_CustomOperator(t *testing.T) {
+	env := environment.New()
+	env.SetDefaultFunctions()
+	operators := []operator.Operator{
+		{Symbol: "**", Precedence: operator.PrecedenceExponent, RightAssociative: true, Impl: func(operands []interface{}) (interface{}, error) {
+			return operands[0].(decimal.Decimal).Pow(operands[1].(decimal.Decimal)), nil
+		}},
+		{Symbol: "~=", Precedence: operator.PrecedenceEquality, Impl: func(operands []interface{}) (interface{}, error) {
+			return operands[0].(decimal.Decimal).Sub(operands[1].(decimal.Decimal)).Abs().LessThan(decimal.NewFromFloat(0.01)), nil
+		}},
+		{Symbol: "contains", Precedence: operator.PrecedenceComparison, Impl: func(operands []interface{}) (interface{}, error) {
+			for _, item := range operands[0].([]interface{}) {
+				if item == operands[1] {
+					return true, nil
+				}
+			}
+			return false, nil
+		}},
+		{Symbol: "$", Kind: operator.Prefix, Precedence: operator.PrecedencePrefix, Impl: func(operands []interface{}) (interface{}, error) {
+			return datatype.GetTypeString(operands[0]), nil
+		}},
+	}
+	for _, op := range operators {
+		if err := env.RegisterOperator(op); err != nil {
+			t.Fatalf("RegisterOperator(%s) error = %v", op.Symbol, err)
+		}
+	}
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"2 ** 3 ** 2", "512"},
+		{"2 * 3 ** 2", "18"},
+		{"0.1 + 0.2 ~= 0.3 && 1 ~= 1.001", "true"},
+		{"1 ~= 1.1", "false"},
+		{"['a', 'b'] contains 'b'", "true"},
+		{"['a', 'b'] contains 'c' || false", "false"},
+		{"contains('chili', 'hi') && ['a'] contains 'a'", "true"},
+		{"map([['x']], xs => xs contains 'x')", "[true]"},
+		{"$1 + 1", "NUMBER1"},
+		{"$'x'", "STRING"},
+		{"`${2 ** 2}`", "4"},
+	}
+	eval := New(env)
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			got, err := run(t, eval, tt.source)
+			if err != nil {
+				t.Fatalf("Evaluator.Run() error = %v", err)
+			}
+			if fmt.Sprintf("%v", got) != tt.want {
+				t.Errorf("Evaluator.Run() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,310 @@
+package evaluator
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/5anthosh/chili/diagnostic"
+	"github.com/5anthosh/chili/evaluator/datatype"
+	"github.com/5anthosh/chili/parser/token"
+	"github.com/shopspring/decimal"
+)
+
+//binaryOperation evaluates builtin binary operator on evaluated operands
+type binaryOperation func(eval *Evaluator, operator *token.Token, left interface{}, right interface{}) (interface{}, error)
+
+//binaryOperations of builtin operators by token type
+var binaryOperations = map[uint]binaryOperation{
+	token.PlusType:           add,
+	token.MinusType:          numberOperation(decimal.Decimal.Sub),
+	token.StarType:           numberOperation(decimal.Decimal.Mul),
+	token.CommonSlashType:    divisionOperation(decimal.Decimal.Div),
+	token.ModType:            divisionOperation(decimal.Decimal.Mod),
+	token.CapType:            numberOperation(decimal.Decimal.Pow),
+	token.EqualType:          equal,
+	token.NotEqualType:       notEqual,
+	token.GreaterType:        comparison(decimal.Decimal.GreaterThan),
+	token.GreaterEqualType:   comparison(decimal.Decimal.GreaterThanOrEqual),
+	token.LesserType:         comparison(decimal.Decimal.LessThan),
+	token.LesserEqualType:    comparison(decimal.Decimal.LessThanOrEqual),
+	token.MatchType:          match,
+	token.NotMatchType:       match,
+	token.FloorSlashType:     integerOperation(floorDivision),
+	token.BitAndType:         integerOperation(bigIntOperation((*big.Int).And)),
+	token.BitOrType:          integerOperation(bigIntOperation((*big.Int).Or)),
+	token.BitXorType:         integerOperation(bigIntOperation((*big.Int).Xor)),
+	token.ShiftLeftType:      integerOperation(shift((*big.Int).Lsh)),
+	token.ShiftRightType:     integerOperation(shift((*big.Int).Rsh)),
+	token.InType:             membership,
+	token.NotInType:          membership,
+	token.RangeType:          rangeOperation(false),
+	token.RangeExclusiveType: rangeOperation(true),
+}
+
+//MaxShift is the largest shift count of << and >>
+const MaxShift = 1024
+
+func add(eval *Evaluator, operator *token.Token, left interface{}, right interface{}) (interface{}, error) {
+	if datatype.CheckNumber(left, right) {
+		return left.(decimal.Decimal).Add(right.(decimal.Decimal)), nil
+	}
+	if datatype.CheckString(left) && datatype.CheckNumber(right) {
+		return left.(string) + (right.(decimal.Decimal)).String(), nil
+	}
+	if datatype.CheckNumber(left) && datatype.CheckString(right) {
+		return (left.(decimal.Decimal)).String() + right.(string), nil
+	}
+	if datatype.CheckList(left, right) {
+		leftList := left.([]interface{})
+		rightList := right.([]interface{})
+		list := make([]interface{}, 0, len(leftList)+len(rightList))
+		list = append(list, leftList...)
+		return append(list, rightList...), nil
+	}
+	return nil, generateUnsupportedOperationErr(operator.Lexeme, left, right)
+}
+
+//numberOperation is arithmetic operation on numbers
+func numberOperation(fn func(decimal.Decimal, decimal.Decimal) decimal.Decimal) binaryOperation {
+	return func(eval *Evaluator, operator *token.Token, left interface{}, right interface{}) (interface{}, error) {
+		if datatype.CheckNumber(left, right) {
+			return fn(left.(decimal.Decimal), right.(decimal.Decimal)), nil
+		}
+		return nil, generateUnsupportedOperationErr(operator.Lexeme, left, right)
+	}
+}
+
+//divisionOperation is arithmetic operation on numbers which fails when right operand is zero
+func divisionOperation(fn func(decimal.Decimal, decimal.Decimal) decimal.Decimal) binaryOperation {
+	return func(eval *Evaluator, operator *token.Token, left interface{}, right interface{}) (interface{}, error) {
+		if datatype.CheckNumber(left, right) {
+			if decimal.Zero.Equals(right.(decimal.Decimal)) {
+				return nil, diagnostic.NewDivisionByZeroError(token.Span{}, ErrDivisionByZero)
+			}
+			return fn(left.(decimal.Decimal), right.(decimal.Decimal)), nil
+		}
+		return nil, generateUnsupportedOperationErr(operator.Lexeme, left, right)
+	}
+}
+
+//comparison of numbers
+func comparison(fn func(decimal.Decimal, decimal.Decimal) bool) binaryOperation {
+	return func(eval *Evaluator, operator *token.Token, left interface{}, right interface{}) (interface{}, error) {
+		if datatype.CheckNumber(left, right) {
+			return fn(left.(decimal.Decimal), right.(decimal.Decimal)), nil
+		}
+		return nil, generateUnsupportedOperationErr(operator.Lexeme, left, right)
+	}
+}
+
+//integerOperation is operation on numbers which must not have fractional part
+func integerOperation(fn func(left *big.Int, right *big.Int) (*big.Int, error)) binaryOperation {
+	return func(eval *Evaluator, operator *token.Token, left interface{}, right interface{}) (interface{}, error) {
+		if !datatype.CheckNumber(left, right) {
+			return nil, generateUnsupportedOperationErr(operator.Lexeme, left, right)
+		}
+		leftInt, err := toInteger(operator.Lexeme, left.(decimal.Decimal))
+		if err != nil {
+			return nil, err
+		}
+		rightInt, err := toInteger(operator.Lexeme, right.(decimal.Decimal))
+		if err != nil {
+			return nil, err
+		}
+		value, err := fn(leftInt, rightInt)
+		if err != nil {
+			return nil, err
+		}
+		return decimal.NewFromBigInt(value, 0), nil
+	}
+}
+
+//toInteger fails with TypeError when number has fractional part
+func toInteger(op string, number decimal.Decimal) (*big.Int, error) {
+	if !number.Equal(number.Truncate(0)) {
+		return nil, diagnostic.NewTypeError(token.Span{}, "%s operation requires integers but got %s", op, number.String())
+	}
+	return number.BigInt(), nil
+}
+
+func bigIntOperation(fn func(z *big.Int, x *big.Int, y *big.Int) *big.Int) func(*big.Int, *big.Int) (*big.Int, error) {
+	return func(left *big.Int, right *big.Int) (*big.Int, error) {
+		return fn(new(big.Int), left, right), nil
+	}
+}
+
+//floorDivision rounds the quotient towards negative infinity
+func floorDivision(left *big.Int, right *big.Int) (*big.Int, error) {
+	if right.Sign() == 0 {
+		return nil, diagnostic.NewDivisionByZeroError(token.Span{}, ErrDivisionByZero)
+	}
+	quotient, remainder := new(big.Int).QuoRem(left, right, new(big.Int))
+	if remainder.Sign() != 0 && remainder.Sign() != right.Sign() {
+		quotient.Sub(quotient, big.NewInt(1))
+	}
+	return quotient, nil
+}
+
+func shift(fn func(z *big.Int, x *big.Int, n uint) *big.Int) func(*big.Int, *big.Int) (*big.Int, error) {
+	return func(left *big.Int, right *big.Int) (*big.Int, error) {
+		if right.Sign() < 0 || right.Cmp(big.NewInt(MaxShift)) > 0 {
+			return nil, diagnostic.NewTypeError(token.Span{}, "shift count must be between 0 and %d but got %s", MaxShift, right.String())
+		}
+		return fn(new(big.Int), left, uint(right.Uint64())), nil
+	}
+}
+
+//membership tests value in list, range, map keys or substring of string
+func membership(eval *Evaluator, operator *token.Token, left interface{}, right interface{}) (interface{}, error) {
+	found, err := contains(operator.Lexeme, right, left)
+	if err != nil {
+		return nil, err
+	}
+	return found == (operator.Type.Type() == token.InType), nil
+}
+
+func contains(op string, collection interface{}, value interface{}) (bool, error) {
+	switch collection.(type) {
+	case []interface{}:
+		for _, item := range collection.([]interface{}) {
+			equal, err := sameTypeEqual(op, value, item)
+			if err != nil {
+				return false, err
+			}
+			if equal {
+				return true, nil
+			}
+		}
+		return false, nil
+	case datatype.Range:
+		if datatype.CheckNumber(value) {
+			//range has only integers, membership requires integer like range bounds
+			_, err := toInteger(op, value.(decimal.Decimal))
+			if err != nil {
+				return false, err
+			}
+			return collection.(datatype.Range).Contains(value.(decimal.Decimal)), nil
+		}
+	case map[string]interface{}:
+		if datatype.CheckString(value) {
+			_, ok := collection.(map[string]interface{})[value.(string)]
+			return ok, nil
+		}
+	case string:
+		if datatype.CheckString(value) {
+			return strings.Contains(collection.(string), value.(string)), nil
+		}
+	}
+	return false, generateUnsupportedOperationErr(op, value, collection)
+}
+
+//rangeOperation creates range from integers
+func rangeOperation(exclusive bool) binaryOperation {
+	return func(eval *Evaluator, operator *token.Token, left interface{}, right interface{}) (interface{}, error) {
+		if !datatype.CheckNumber(left, right) {
+			return nil, generateUnsupportedOperationErr(operator.Lexeme, left, right)
+		}
+		start, err := toInteger(operator.Lexeme, left.(decimal.Decimal))
+		if err != nil {
+			return nil, err
+		}
+		end, err := toInteger(operator.Lexeme, right.(decimal.Decimal))
+		if err != nil {
+			return nil, err
+		}
+		return datatype.Range{
+			Start:     decimal.NewFromBigInt(start, 0),
+			End:       decimal.NewFromBigInt(end, 0),
+			Exclusive: exclusive,
+		}, nil
+	}
+}
+
+func equal(eval *Evaluator, operator *token.Token, left interface{}, right interface{}) (interface{}, error) {
+	return logicalOperation(operator.Lexeme, left, right)
+}
+
+func notEqual(eval *Evaluator, operator *token.Token, left interface{}, right interface{}) (interface{}, error) {
+	value, err := logicalOperation(operator.Lexeme, left, right)
+	if err != nil {
+		return nil, err
+	}
+	return !value, nil
+}
+
+func match(eval *Evaluator, operator *token.Token, left interface{}, right interface{}) (interface{}, error) {
+	if datatype.CheckString(left, right) {
+		re, err := eval.functionContext().Regex.Compile(right.(string))
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString(left.(string)) == (operator.Type.Type() == token.MatchType), nil
+	}
+	return nil, generateUnsupportedOperationErr(operator.Lexeme, left, right)
+}
+
+//generateUnsupportedOperationErr is TypeError which is located at the operation by accept
+func generateUnsupportedOperationErr(op string, left interface{}, right interface{}) error {
+	return diagnostic.NewTypeError(token.Span{}, "%s operation between (%s, %s) is not supported", op, datatype.GetTypeString(left), datatype.GetTypeString(right))
+}
+
+func logicalOperation(op string, left interface{}, right interface{}) (bool, error) {
+	if left == nil || right == nil {
+		return left == nil && right == nil, nil
+	}
+	if datatype.CheckNumber(left, right) {
+		return left.(decimal.Decimal).Equals(right.(decimal.Decimal)), nil
+	}
+	if datatype.CheckString(left, right) {
+		return left.(string) == right.(string), nil
+	}
+	if datatype.CheckBoolean(left, right) {
+		return left.(bool) == right.(bool), nil
+	}
+	if datatype.CheckList(left, right) {
+		leftList := left.([]interface{})
+		rightList := right.([]interface{})
+		if len(leftList) != len(rightList) {
+			return false, nil
+		}
+		for i := range leftList {
+			equal, err := sameTypeEqual(op, leftList[i], rightList[i])
+			if err != nil || !equal {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+	if datatype.CheckRange(left, right) {
+		return left.(datatype.Range).Equal(right.(datatype.Range)), nil
+	}
+	if datatype.CheckMap(left, right) {
+		leftMap := left.(map[string]interface{})
+		rightMap := right.(map[string]interface{})
+		if len(leftMap) != len(rightMap) {
+			return false, nil
+		}
+		for key, leftValue := range leftMap {
+			rightValue, ok := rightMap[key]
+			if !ok {
+				return false, nil
+			}
+			equal, err := sameTypeEqual(op, leftValue, rightValue)
+			if err != nil || !equal {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+	return false, generateUnsupportedOperationErr(op, left, right)
+}
+
+//sameTypeEqual compares values of collections, values of different type are never equal
+func sameTypeEqual(op string, left interface{}, right interface{}) (bool, error) {
+	leftType, _ := datatype.GetType(left)
+	rightType, _ := datatype.GetType(right)
+	if leftType != rightType {
+		return false, nil
+	}
+	return logicalOperation(op, left, right)
+}
@@ -0,0 +1,217 @@
+package function
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/5anthosh/chili/diagnostic"
+	"github.com/5anthosh/chili/evaluator/datatype"
+	"github.com/5anthosh/chili/parser/token"
+	"github.com/shopspring/decimal"
+)
+
+func mapImpl(args []interface{}) (interface{}, error) {
+	list := args[0].([]interface{})
+	fn := args[1].(datatype.Callable)
+	result := make([]interface{}, 0, len(list))
+	for _, item := range list {
+		value, err := fn.Call([]interface{}{item})
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+func filterImpl(args []interface{}) (interface{}, error) {
+	list := args[0].([]interface{})
+	fn := args[1].(datatype.Callable)
+	result := make([]interface{}, 0)
+	for _, item := range list {
+		value, err := fn.Call([]interface{}{item})
+		if err != nil {
+			return nil, err
+		}
+		if datatype.Truthy(value) {
+			result = append(result, item)
+		}
+	}
+	return result, nil
+}
+
+func reduceImpl(args []interface{}) (interface{}, error) {
+	list := args[0].([]interface{})
+	fn := args[1].(datatype.Callable)
+	accumulator := args[2]
+	for _, item := range list {
+		value, err := fn.Call([]interface{}{accumulator, item})
+		if err != nil {
+			return nil, err
+		}
+		accumulator = value
+	}
+	return accumulator, nil
+}
+
+func anyImpl(args []interface{}) (interface{}, error) {
+	list := args[0].([]interface{})
+	fn := args[1].(datatype.Callable)
+	for _, item := range list {
+		value, err := fn.Call([]interface{}{item})
+		if err != nil {
+			return nil, err
+		}
+		if datatype.Truthy(value) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func allImpl(args []interface{}) (interface{}, error) {
+	list := args[0].([]interface{})
+	fn := args[1].(datatype.Callable)
+	for _, item := range list {
+		value, err := fn.Call([]interface{}{item})
+		if err != nil {
+			return nil, err
+		}
+		if !datatype.Truthy(value) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func sortByImpl(args []interface{}) (interface{}, error) {
+	list := args[0].([]interface{})
+	fn := args[1].(datatype.Callable)
+	keys := make([]interface{}, len(list))
+	for i, item := range list {
+		key, err := fn.Call([]interface{}{item})
+		if err != nil {
+			return nil, err
+		}
+		if !datatype.CheckNumber(key) && !datatype.CheckString(key) {
+			return nil, diagnostic.NewTypeError(token.Span{}, "sortBy() key must be NUMBER or STRING but got %s", datatype.GetTypeString(key))
+		}
+		if i > 0 && datatype.GetTypeString(key) != datatype.GetTypeString(keys[0]) {
+			return nil, diagnostic.NewTypeError(token.Span{}, "sortBy() keys must be of same type")
+		}
+		keys[i] = key
+	}
+	indices := make([]int, len(list))
+	for i := range indices {
+		indices[i] = i
+	}
+	sort.SliceStable(indices, func(i, j int) bool {
+		return compareKeys(keys[indices[i]], keys[indices[j]]) < 0
+	})
+	result := make([]interface{}, len(list))
+	for i, index := range indices {
+		result[i] = list[index]
+	}
+	return result, nil
+}
+
+func compareKeys(a interface{}, b interface{}) int {
+	if datatype.CheckNumber(a) {
+		return a.(decimal.Decimal).Cmp(b.(decimal.Decimal))
+	}
+	return strings.Compare(a.(string), b.(string))
+}
+
+func groupByImpl(args []interface{}) (interface{}, error) {
+	list := args[0].([]interface{})
+	fn := args[1].(datatype.Callable)
+	groups := make(map[string]interface{})
+	for _, item := range list {
+		key, err := fn.Call([]interface{}{item})
+		if err != nil {
+			return nil, err
+		}
+		var name string
+		switch key.(type) {
+		case string:
+			name = key.(string)
+		case decimal.Decimal:
+			name = key.(decimal.Decimal).String()
+		case bool:
+			name = fmt.Sprintf("%v", key)
+		default:
+			return nil, diagnostic.NewTypeError(token.Span{}, "groupBy() key must be STRING, NUMBER or BOOLEAN but got %s", datatype.GetTypeString(key))
+		}
+		group, _ := groups[name].([]interface{})
+		groups[name] = append(group, item)
+	}
+	return groups, nil
+}
+
+// Collection functions
+var (
+	MapFunction = Function{
+		Name:          "map",
+		Arity:         2,
+		FunctionImpl:  mapImpl,
+		ParamsType:    []uint{datatype.ListType, datatype.CallableType},
+		Params:        []string{"list", "lambda"},
+		ReturnType:    datatype.ListType,
+		Documentation: "Applies a lambda to every item of a list.\n Returns a list.",
+	}
+	FilterFunction = Function{
+		Name:          "filter",
+		Arity:         2,
+		FunctionImpl:  filterImpl,
+		ParamsType:    []uint{datatype.ListType, datatype.CallableType},
+		Params:        []string{"list", "lambda"},
+		ReturnType:    datatype.ListType,
+		Documentation: "Keeps the items of a list for which the lambda returns true.\n Returns a list.",
+	}
+	ReduceFunction = Function{
+		Name:          "reduce",
+		Arity:         3,
+		FunctionImpl:  reduceImpl,
+		ParamsType:    []uint{datatype.ListType, datatype.CallableType, datatype.GenerictType},
+		Params:        []string{"list", "lambda", "initial"},
+		ReturnType:    datatype.GenerictType,
+		Documentation: "Combines the items of a list using a lambda (accumulator, item), starting from the initial value.\n Returns the accumulated value.",
+	}
+	AnyFunction = Function{
+		Name:          "any",
+		Arity:         2,
+		FunctionImpl:  anyImpl,
+		ParamsType:    []uint{datatype.ListType, datatype.CallableType},
+		Params:        []string{"list", "lambda"},
+		ReturnType:    datatype.BooleanType,
+		Documentation: "Tests whether the lambda returns true for any item of a list.\n Returns a boolean.",
+	}
+	AllFunction = Function{
+		Name:          "all",
+		Arity:         2,
+		FunctionImpl:  allImpl,
+		ParamsType:    []uint{datatype.ListType, datatype.CallableType},
+		Params:        []string{"list", "lambda"},
+		ReturnType:    datatype.BooleanType,
+		Documentation: "Tests whether the lambda returns true for all items of a list.\n Returns a boolean.",
+	}
+	SortByFunction = Function{
+		Name:          "sortBy",
+		Arity:         2,
+		FunctionImpl:  sortByImpl,
+		ParamsType:    []uint{datatype.ListType, datatype.CallableType},
+		Params:        []string{"list", "lambda"},
+		ReturnType:    datatype.ListType,
+		Documentation: "Sorts a list in ascending order of the key returned by the lambda.\n Returns a list.",
+	}
+	GroupByFunction = Function{
+		Name:          "groupBy",
+		Arity:         2,
+		FunctionImpl:  groupByImpl,
+		ParamsType:    []uint{datatype.ListType, datatype.CallableType},
+		Params:        []string{"list", "lambda"},
+		ReturnType:    datatype.MapType,
+		Documentation: "Groups the items of a list by the key returned by the lambda.\n Returns a map of lists.",
+	}
+)
@@ -4,7 +4,9 @@ import (
 	"math"
 	"strings"
 
+	"github.com/5anthosh/chili/diagnostic"
 	"github.com/5anthosh/chili/evaluator/datatype"
+	"github.com/5anthosh/chili/parser/token"
 	"github.com/shopspring/decimal"
 )
 
@@ -91,10 +93,20 @@ func powImpl(args []interface{}) (interface{}, error) {
 	return base.Pow(power), nil
 }
 
+//MaxRoundPlaces limits places of round() in both directions, larger places make
+//decimal produce that many digits
+const MaxRoundPlaces = 1000
+
 func roundImpl(args []interface{}) (interface{}, error) {
 	arg := args[0].(decimal.Decimal)
-	v, _ := arg.Float64()
-	return decimal.NewFromFloat(math.Round(v)), nil
+	places := args[1].(decimal.Decimal)
+	if !places.Equal(places.Truncate(0)) {
+		return nil, diagnostic.NewTypeError(token.Span{}, "round() places %s is not an integer", places.String())
+	}
+	if places.Abs().GreaterThan(decimal.NewFromInt(MaxRoundPlaces)) {
+		return nil, diagnostic.NewTypeError(token.Span{}, "round() places %s is out of range, expecting between -%d and %d", places.String(), MaxRoundPlaces, MaxRoundPlaces)
+	}
+	return arg.Round(int32(places.IntPart())), nil
 }
 
 func signImpl(args []interface{}) (interface{}, error) {
@@ -134,26 +146,22 @@ func containsImpl(args []interface{}) (interface{}, error) {
 
 func joinImpl(args []interface{}) (interface{}, error) {
 	delimiter := args[0].(string)
-	var builder strings.Builder
-	_, err := builder.WriteString(args[1].(string))
-	if err != nil {
-		return nil, err
-	}
-	for i := 2; i < len(args); i++ {
-		_, err = builder.WriteString(delimiter)
-		if err != nil {
-			return nil, err
-		}
-		_, err = builder.WriteString(args[i].(string))
-		if err != nil {
-			return nil, err
+	values := make([]string, 0, len(args)-1)
+	for _, arg := range args[1:] {
+		if number, ok := arg.(decimal.Decimal); ok {
+			values = append(values, number.String())
+			continue
 		}
+		values = append(values, arg.(string))
 	}
-	return builder.String(), nil
+	return strings.Join(values, delimiter), nil
 }
 
 func lengthImpl(args []interface{}) (interface{}, error) {
-	return len(args[0].(string)), nil
+	if list, ok := args[0].([]interface{}); ok {
+		return decimal.NewFromInt(int64(len(list))), nil
+	}
+	return decimal.NewFromInt(int64(len(args[0].(string)))), nil
 }
 
 func replaceImpl(args []interface{}) (interface{}, error) {
@@ -173,15 +181,41 @@ func replaceAllImpl(args []interface{}) (interface{}, error) {
 
 func sliceImpl(args []interface{}) (interface{}, error) {
 	str := args[0].(string)
-	start := args[1].(decimal.Decimal).IntPart()
-	stop := args[2].(decimal.Decimal).IntPart()
-	if start < 0 {
-		start = 0
+	start, stop := sliceBounds(args[1], args[2], len(str))
+	return str[start:stop], nil
+}
+
+func listSliceImpl(args []interface{}) (interface{}, error) {
+	list := args[0].([]interface{})
+	start, stop := sliceBounds(args[1], args[2], len(list))
+	return append([]interface{}{}, list[start:stop]...), nil
+}
+
+//sliceBounds limits start and end of slice within 0 and length
+func sliceBounds(startArg interface{}, stopArg interface{}, length int) (int64, int64) {
+	stop := clampIndex(stopArg.(decimal.Decimal), length)
+	start := clampIndex(startArg.(decimal.Decimal), length)
+	if start > stop {
+		start = stop
 	}
-	if stop > int64(len(str)) {
-		stop = int64(len(str))
+	return start, stop
+}
+
+//clampIndex limits index within 0 and length, it is clamped in decimal
+//since IntPart wraps for indices beyond int64
+func clampIndex(index decimal.Decimal, length int) int64 {
+	if index.IsNegative() {
+		return 0
 	}
-	return str[start:stop], nil
+	if index.GreaterThan(decimal.NewFromInt(int64(length))) {
+		return int64(length)
+	}
+	return index.IntPart()
+}
+
+//propImpl looks up variable by name like quoted identifier @"name"
+func propImpl(ctx *Context, args []interface{}) (interface{}, error) {
+	return ctx.Variable(args[0].(string))
 }
 
 // Functions
@@ -191,6 +225,7 @@ var (
 		Arity:         1,
 		FunctionImpl:  absImpl,
 		ParamsType:    []uint{datatype.NumberType},
+		Params:        []string{"number"},
 		ReturnType:    datatype.NumberType,
 		Documentation: "Returns the absolute value of a number.\n Returns a number.",
 	}
@@ -199,6 +234,7 @@ var (
 		Arity:         1,
 		FunctionImpl:  cbrtImpl,
 		ParamsType:    []uint{datatype.NumberType},
+		Params:        []string{"number"},
 		ReturnType:    datatype.NumberType,
 		Documentation: "Returns the cube root of a number.\n Returns a number.",
 	}
@@ -207,6 +243,7 @@ var (
 		Arity:         1,
 		FunctionImpl:  ceilImpl,
 		ParamsType:    []uint{datatype.NumberType},
+		Params:        []string{"number"},
 		ReturnType:    datatype.NumberType,
 		Documentation: "Returns the smallest integer greater than or equal to a number.\n Returns a number.",
 	}
@@ -215,6 +252,7 @@ var (
 		Arity:         1,
 		FunctionImpl:  expImpl,
 		ParamsType:    []uint{datatype.NumberType},
+		Params:        []string{"number"},
 		ReturnType:    datatype.NumberType,
 		Documentation: "Returns E^x, where x is the argument, and E is Euler's constant (2.718…), the base of the natural logarithm.\n Returns a number.",
 	}
@@ -223,6 +261,7 @@ var (
 		Arity:         1,
 		FunctionImpl:  floorImpl,
 		ParamsType:    []uint{datatype.NumberType},
+		Params:        []string{"number"},
 		ReturnType:    datatype.NumberType,
 		Documentation: "Returns the largest integer less than or equal to a number.\n Returns a number.",
 	}
@@ -231,6 +270,7 @@ var (
 		Arity:         1,
 		FunctionImpl:  lnImpl,
 		ParamsType:    []uint{datatype.NumberType},
+		Params:        []string{"number"},
 		ReturnType:    datatype.NumberType,
 		Documentation: "Returns the natural logarithm of a number.\n Returns a number.",
 	}
@@ -239,6 +279,7 @@ var (
 		Arity:         1,
 		FunctionImpl:  log10Impl,
 		ParamsType:    []uint{datatype.NumberType},
+		Params:        []string{"number"},
 		ReturnType:    datatype.NumberType,
 		Documentation: "Returns the base 10 logarithm of a number.\n Returns a number.",
 	}
@@ -247,6 +288,7 @@ var (
 		Arity:         1,
 		FunctionImpl:  log2Impl,
 		ParamsType:    []uint{datatype.NumberType},
+		Params:        []string{"number"},
 		ReturnType:    datatype.NumberType,
 		Documentation: "Returns the base 2 logarithm of a number.\n Returns a number.",
 	}
@@ -275,22 +317,26 @@ var (
 		Arity:         2,
 		FunctionImpl:  powImpl,
 		ParamsType:    []uint{datatype.NumberType, datatype.NumberType},
+		Params:        []string{"base", "exponent"},
 		ReturnType:    datatype.NumberType,
 		Documentation: "Returns base to the exponent power.\n Returns a number.",
 	}
 	RoundFunction = Function{
 		Name:          "round",
-		Arity:         1,
+		Arity:         2,
 		FunctionImpl:  roundImpl,
-		ParamsType:    []uint{datatype.NumberType},
+		ParamsType:    []uint{datatype.NumberType, datatype.NumberType},
+		Params:        []string{"number", "places"},
+		Defaults:      map[string]interface{}{"places": decimal.Zero},
 		ReturnType:    datatype.NumberType,
-		Documentation: "Rounds a number to the nearest integer.\n Returns a number.",
+		Documentation: "Rounds a number to the given decimal places, nearest integer by default.\n Returns a number.",
 	}
 	SignFunction = Function{
 		Name:          "sign",
 		Arity:         1,
 		FunctionImpl:  signImpl,
 		ParamsType:    []uint{datatype.NumberType},
+		Params:        []string{"number"},
 		ReturnType:    datatype.NumberType,
 		Documentation: "Returns the sign of a number, indicating whether it's positive (1), negative (-1) or zero (0).\n Returns a number.",
 	}
@@ -299,6 +345,7 @@ var (
 		Arity:         1,
 		FunctionImpl:  sqrtImpl,
 		ParamsType:    []uint{datatype.NumberType},
+		Params:        []string{"number"},
 		ReturnType:    datatype.NumberType,
 		Documentation: "Returns the square root of a number.\n Returns a number.",
 	}
@@ -307,6 +354,7 @@ var (
 		Arity:         1,
 		FunctionImpl:  toNumberImpl,
 		ParamsType:    []uint{datatype.StringType},
+		Params:        []string{"text"},
 		ReturnType:    datatype.NumberType,
 		Documentation: "Converts a text string to a number.\n Returns a number.",
 	}
@@ -327,28 +375,32 @@ var (
 		Arity:         2,
 		FunctionImpl:  containsImpl,
 		ParamsType:    []uint{datatype.StringType, datatype.StringType},
+		Params:        []string{"text", "search"},
 		ReturnType:    datatype.BooleanType,
 		Documentation: "Tests whether a text string contains another text string.\n Returns a boolean.",
 	}
 
 	JoinFunction = Function{
-		Name:          "join",
-		Arity:         -1,
-		MinArity:      2,
-		MaxArity:      MaximumNumberOfParamsLimit,
-		FunctionImpl:  joinImpl,
-		ParamsType:    []uint{datatype.StringType},
+		Name:         "join",
+		FunctionImpl: joinImpl,
+		Signatures: []Signature{
+			{
+				Params:     []string{"delimiter", "value", "values"},
+				ParamsType: []Types{{datatype.StringType}, {datatype.StringType, datatype.NumberType}, {datatype.StringType, datatype.NumberType}},
+				Variadic:   true,
+			},
+		},
 		ReturnType:    datatype.StringType,
-		Documentation: "Combines text strings, with a specified delimiter.\n Returns a text string.",
+		Documentation: "Combines text strings and numbers, with a specified delimiter.\n Returns a text string.",
 	}
 
 	LengthFunction = Function{
 		Name:          "length",
-		Arity:         1,
 		FunctionImpl:  lengthImpl,
-		ParamsType:    []uint{datatype.StringType},
+		Params:        []string{"value"},
+		Signatures:    []Signature{{Params: []string{"value"}, ParamsType: []Types{{datatype.StringType, datatype.ListType}}}},
 		ReturnType:    datatype.NumberType,
-		Documentation: "Returns the number of characters in a text string.\n Returns a number.",
+		Documentation: "Returns the number of characters in a text string or items in a list.\n Returns a number.",
 	}
 
 	ReplaceFunction = Function{
@@ -356,6 +408,7 @@ var (
 		Arity:         4,
 		FunctionImpl:  replaceImpl,
 		ParamsType:    []uint{datatype.StringType, datatype.StringType, datatype.StringType, datatype.NumberType},
+		Params:        []string{"text", "search", "replacement", "count"},
 		ReturnType:    datatype.StringType,
 		Documentation: "Replaces the n match within a text string with a specified new text string.\n Returns a text string.",
 	}
@@ -364,17 +417,38 @@ var (
 		Arity:         3,
 		FunctionImpl:  replaceAllImpl,
 		ParamsType:    []uint{datatype.StringType, datatype.StringType, datatype.StringType},
+		Params:        []string{"text", "search", "replacement"},
 		ReturnType:    datatype.StringType,
 		Documentation: "Replaces all matches within a text string with a specified new text string.\n Returns a text string.",
 	}
 
 	SliceFunction = Function{
-		Name:          "slice",
-		Arity:         3,
-		FunctionImpl:  sliceImpl,
-		ParamsType:    []uint{datatype.StringType, datatype.NumberType, datatype.NumberType},
-		ReturnType:    datatype.StringType,
-		Documentation: "Extracts a substring from a text string, given a specified starting point and  end point.\n Returns a text string",
+		Name:   "slice",
+		Params: []string{"value", "start", "stop"},
+		Signatures: []Signature{
+			{
+				Params:       []string{"text", "start", "stop"},
+				ParamsType:   []Types{{datatype.StringType}, {datatype.NumberType}, {datatype.NumberType}},
+				FunctionImpl: sliceImpl,
+			},
+			{
+				Params:       []string{"list", "start", "stop"},
+				ParamsType:   []Types{{datatype.ListType}, {datatype.NumberType}, {datatype.NumberType}},
+				FunctionImpl: listSliceImpl,
+			},
+		},
+		ReturnType:    datatype.GenerictType,
+		Documentation: "Extracts a substring from a text string or items from a list, given a specified starting point and end point.\n Returns a text string or a list",
+	}
+
+	PropFunction = Function{
+		Name:                "prop",
+		Arity:               1,
+		ContextFunctionImpl: propImpl,
+		ParamsType:          []uint{datatype.StringType},
+		Params:              []string{"name"},
+		ReturnType:          datatype.GenerictType,
+		Documentation:       "Returns the value of a variable by its name, names may contain spaces and symbols.\n Returns the value of the variable.",
 	}
 	DefaultFunctions = []Function{
 		AbsFunction,
@@ -399,5 +473,17 @@ var (
 		ReplaceFunction,
 		ReplaceAllFunction,
 		SliceFunction,
+		PropFunction,
+		MapFunction,
+		FilterFunction,
+		ReduceFunction,
+		AnyFunction,
+		AllFunction,
+		SortByFunction,
+		GroupByFunction,
+		RegexMatchFunction,
+		RegexExtractFunction,
+		RegexExtractAllFunction,
+		RegexReplaceFunction,
 	}
 )
@@ -1,9 +1,11 @@
 package function
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 
+	"github.com/5anthosh/chili/diagnostic"
 	"github.com/shopspring/decimal"
 )
 
@@ -142,3 +144,64 @@ func Test_ceilImpl(t *testing.T) {
 		})
 	}
 }
+
+func Test_roundImpl(t *testing.T) {
+	tests := []testArgs{
+		{
+			name: "round to places",
+			args: args{
+				args: []interface{}{decimal.NewFromFloat(12.3456), two},
+			},
+			want:    "12.35",
+			wantErr: false,
+		},
+		{
+			name: "round to negative places",
+			args: args{
+				args: []interface{}{decimal.NewFromInt(1234), two.Neg()},
+			},
+			want:    "1200",
+			wantErr: false,
+		},
+		{
+			name: "round to fractional places",
+			args: args{
+				args: []interface{}{decimal.NewFromFloat(12.3456), decimal.NewFromFloat(1.7)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "round to too many places",
+			args: args{
+				args: []interface{}{one, decimal.New(1, 10)},
+			},
+			wantErr: true,
+		},
+		{
+			name: "round to too many negative places",
+			args: args{
+				args: []interface{}{one, decimal.NewFromInt(-MaxRoundPlaces - 1)},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := roundImpl(tt.args.args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("roundImpl() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				var typeErr *diagnostic.TypeError
+				if !errors.As(err, &typeErr) {
+					t.Errorf("roundImpl() error = %T, want *diagnostic.TypeError", err)
+				}
+				return
+			}
+			if got.(decimal.Decimal).String() != tt.want {
+				t.Errorf("roundImpl() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,62 +2,144 @@ package function
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/5anthosh/chili/diagnostic"
 	"github.com/5anthosh/chili/evaluator/datatype"
+	"github.com/5anthosh/chili/parser/ast/expr"
+	"github.com/5anthosh/chili/parser/token"
 )
 
 //MaximumNumberOfParamsLimit for the function
 const MaximumNumberOfParamsLimit = 255
 
+//Context is evaluator owned state shared with function implementations,
+//functions which need it set ContextFunctionImpl instead of FunctionImpl
+type Context struct {
+	Regex *RegexCache
+	//Variable resolves variable by name in the current scope of the evaluator
+	Variable func(name string) (interface{}, error)
+}
+
 //Function struct
 type Function struct {
-	Name               string
-	Arity              int
-	MinArity           uint
-	MaxArity           uint
-	FunctionImpl       func(args []interface{}) (interface{}, error)
-	ParamsType         []uint
-	VerifyArgs         func(arguments []interface{}) error
+	Name                string
+	Arity               int
+	MinArity            uint
+	MaxArity            uint
+	FunctionImpl        func(args []interface{}) (interface{}, error)
+	ContextFunctionImpl func(ctx *Context, args []interface{}) (interface{}, error)
+	ParamsType          []uint
+	VerifyArgs          func(arguments []interface{}) error
+	//Signatures of the overloads, the first one which accepts the arguments is called,
+	//function without Signatures is described by Arity, MinArity, MaxArity and ParamsType
+	Signatures         []Signature
 	ReturnType         uint
 	Documentation      string
 	ArgsDocumentation  string
 	ExampleDocumention string
+	//Params are names of the parameters, they are needed for named arguments
+	Params []string
+	//Defaults of optional parameters by name, missing arguments take them
+	Defaults map[string]interface{}
+	Body     expr.Expr
+	//Source of user function defined separately from the expression calling it,
+	//errors in Body are positioned in this source
+	Source string
+}
+
+//UserFunction creates function whose body is chili expression
+func UserFunction(name string, params []string, body expr.Expr) Function {
+	return Function{
+		Name:          name,
+		Arity:         len(params),
+		Params:        params,
+		Body:          body,
+		ReturnType:    datatype.GenerictType,
+		Documentation: fmt.Sprintf("User defined function %s(%s)", name, strings.Join(params, ", ")),
+	}
+}
+
+//IsUserFunction tells whether function body is chili expression
+func (f *Function) IsUserFunction() bool {
+	return f.Body != nil
 }
 
 //CheckNumberOfArgs in the function
 func (f *Function) CheckNumberOfArgs(arguments []interface{}) error {
-	if f.Arity == -1 {
-		if len(arguments) < int(f.MinArity) {
-			return fmt.Errorf("%s() expecting minimum %d arguments", f.Name, f.MinArity)
-		}
-		if len(arguments) > int(f.MaxArity) {
-			return fmt.Errorf("%s() expecting maximum %d arguments", f.Name, f.MaxArity)
+	signatures := f.signatures()
+	for i := range signatures {
+		if signatures[i].acceptsArity(len(arguments)) {
+			return nil
 		}
-		return nil
 	}
-	if f.Arity != len(arguments) {
-		return fmt.Errorf("%s() expecting %d arguments but got %d", f.Name, f.Arity, len(arguments))
+	if len(signatures) == 1 {
+		return f.arityErr(&signatures[0], len(arguments))
 	}
-	return nil
+	return f.overloadErr(signatures, arguments)
 }
 
-//CheckTypeOfArgs in the function
-func (f *Function) CheckTypeOfArgs(arguments []interface{}) bool {
-	if f.Arity == -1 {
-		dtype := f.ParamsType[0]
-		for _, arg := range arguments {
-			if !datatype.Checkdatatype(arg, dtype) {
-				return false
+//BindArgs orders arguments by Params, names are aligned with arguments and
+//empty for positional ones. Parameters without argument take their Defaults
+func (f *Function) BindArgs(arguments []interface{}, names []string) ([]interface{}, error) {
+	named := false
+	for _, name := range names {
+		if name != "" {
+			named = true
+			break
+		}
+	}
+	if !named && (len(f.Defaults) == 0 || len(arguments) >= len(f.Params)) {
+		return arguments, nil
+	}
+	if len(f.Params) == 0 {
+		return nil, diagnostic.NewArityError(token.Span{}, f.Name, "%s() does not accept named arguments", f.Name)
+	}
+	bound := make([]interface{}, len(f.Params))
+	set := make([]bool, len(f.Params))
+	var rest []interface{}
+	for i, arg := range arguments {
+		if i >= len(names) || names[i] == "" {
+			if i >= len(f.Params) {
+				rest = append(rest, arg)
+				continue
 			}
+			bound[i], set[i] = arg, true
+			continue
+		}
+		index := f.paramIndex(names[i])
+		if index == -1 {
+			return nil, diagnostic.NewArityError(token.Span{}, f.Name, "%s() has no parameter named %s, parameters are (%s)", f.Name, names[i], strings.Join(f.Params, ", "))
 		}
+		if set[index] {
+			return nil, diagnostic.NewArityError(token.Span{}, f.Name, "%s() got multiple values for argument %s", f.Name, names[i])
+		}
+		bound[index], set[index] = arg, true
 	}
+	for i, param := range f.Params {
+		if set[i] {
+			continue
+		}
+		value, ok := f.Defaults[param]
+		if !ok {
+			return nil, diagnostic.NewArityError(token.Span{}, f.Name, "%s() missing argument %s", f.Name, param)
+		}
+		bound[i] = value
+	}
+	return append(bound, rest...), nil
+}
 
-	paramsLen := len(f.ParamsType)
-	argsLen := len(arguments)
-	for i := 0; i < paramsLen && i < argsLen; i++ {
-		if !datatype.Checkdatatype(arguments[i], f.ParamsType[i]) {
-			return false
+func (f *Function) paramIndex(name string) int {
+	for i, param := range f.Params {
+		if param == name {
+			return i
 		}
 	}
-	return true
+	return -1
+}
+
+//CheckTypeOfArgs in the function, Resolve tells which argument is wrong
+func (f *Function) CheckTypeOfArgs(arguments []interface{}) bool {
+	_, _, err := f.Resolve(arguments)
+	return err == nil
 }
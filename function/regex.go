@@ -0,0 +1,179 @@
+package function
+
+import (
+	"container/list"
+	"regexp"
+	"sync"
+
+	"github.com/5anthosh/chili/diagnostic"
+	"github.com/5anthosh/chili/evaluator/datatype"
+	"github.com/5anthosh/chili/parser/token"
+)
+
+//DefaultRegexCacheSize is number of compiled patterns kept by NewRegexCache
+const DefaultRegexCacheSize = 256
+
+//RegexCache keeps recently used compiled patterns so that same pattern is compiled
+//only once, least recently used pattern is evicted when the cache is full
+type RegexCache struct {
+	mutex    sync.Mutex
+	size     int
+	patterns map[string]*list.Element
+	recent   *list.List
+}
+
+//regexEntry is element of recent list
+type regexEntry struct {
+	pattern string
+	re      *regexp.Regexp
+}
+
+//NewRegexCache creates empty cache of DefaultRegexCacheSize patterns
+func NewRegexCache() *RegexCache {
+	return NewRegexCacheWithSize(DefaultRegexCacheSize)
+}
+
+//NewRegexCacheWithSize creates empty cache which keeps at most size patterns
+func NewRegexCacheWithSize(size int) *RegexCache {
+	if size < 1 {
+		size = 1
+	}
+	return &RegexCache{size: size, patterns: make(map[string]*list.Element), recent: list.New()}
+}
+
+//Compile pattern or returns already compiled one, invalid pattern is ValueError
+func (c *RegexCache) Compile(pattern string) (*regexp.Regexp, error) {
+	c.mutex.Lock()
+	element, ok := c.patterns[pattern]
+	if ok {
+		c.recent.MoveToFront(element)
+	}
+	c.mutex.Unlock()
+	if ok {
+		return element.Value.(*regexEntry).re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, diagnostic.NewValueError(token.Span{}, "Invalid regular expression %q: %v", pattern, err)
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if element, ok := c.patterns[pattern]; ok {
+		c.recent.MoveToFront(element)
+		return element.Value.(*regexEntry).re, nil
+	}
+	c.patterns[pattern] = c.recent.PushFront(&regexEntry{pattern: pattern, re: re})
+	if c.recent.Len() > c.size {
+		oldest := c.recent.Back()
+		c.recent.Remove(oldest)
+		delete(c.patterns, oldest.Value.(*regexEntry).pattern)
+	}
+	return re, nil
+}
+
+//Len is number of patterns in the cache
+func (c *RegexCache) Len() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.recent.Len()
+}
+
+//RegexPatternArg is position of pattern argument in regex functions
+const RegexPatternArg = 1
+
+func regexMatchImpl(ctx *Context, args []interface{}) (interface{}, error) {
+	re, err := ctx.Regex.Compile(args[1].(string))
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString(args[0].(string)), nil
+}
+
+func regexExtractImpl(ctx *Context, args []interface{}) (interface{}, error) {
+	re, err := ctx.Regex.Compile(args[1].(string))
+	if err != nil {
+		return nil, err
+	}
+	match := re.FindStringSubmatch(args[0].(string))
+	if match == nil {
+		return nil, nil
+	}
+	return extractedValue(match), nil
+}
+
+func regexExtractAllImpl(ctx *Context, args []interface{}) (interface{}, error) {
+	re, err := ctx.Regex.Compile(args[1].(string))
+	if err != nil {
+		return nil, err
+	}
+	matches := re.FindAllStringSubmatch(args[0].(string), -1)
+	result := make([]interface{}, 0, len(matches))
+	for _, match := range matches {
+		result = append(result, extractedValue(match))
+	}
+	return result, nil
+}
+
+//extractedValue is first capture group if pattern has one, otherwise the whole match
+func extractedValue(match []string) string {
+	if len(match) > 1 {
+		return match[1]
+	}
+	return match[0]
+}
+
+func regexReplaceImpl(ctx *Context, args []interface{}) (interface{}, error) {
+	re, err := ctx.Regex.Compile(args[1].(string))
+	if err != nil {
+		return nil, err
+	}
+	return re.ReplaceAllString(args[0].(string), args[2].(string)), nil
+}
+
+// Regex functions
+var (
+	RegexMatchFunction = Function{
+		Name:                "regexMatch",
+		Arity:               2,
+		ContextFunctionImpl: regexMatchImpl,
+		ParamsType:          []uint{datatype.StringType, datatype.StringType},
+		Params:              []string{"text", "pattern"},
+		ReturnType:          datatype.BooleanType,
+		Documentation:       "Tests whether a text string matches a regular expression.\n Returns a boolean.",
+	}
+	RegexExtractFunction = Function{
+		Name:                "regexExtract",
+		Arity:               2,
+		ContextFunctionImpl: regexExtractImpl,
+		ParamsType:          []uint{datatype.StringType, datatype.StringType},
+		Params:              []string{"text", "pattern"},
+		ReturnType:          datatype.StringType,
+		Documentation:       "Extracts the first match (or its first capture group) of a regular expression, null if nothing matches.\n Returns a text string.",
+	}
+	RegexExtractAllFunction = Function{
+		Name:                "regexExtractAll",
+		Arity:               2,
+		ContextFunctionImpl: regexExtractAllImpl,
+		ParamsType:          []uint{datatype.StringType, datatype.StringType},
+		Params:              []string{"text", "pattern"},
+		ReturnType:          datatype.ListType,
+		Documentation:       "Extracts all matches (or their first capture group) of a regular expression.\n Returns a list of text strings.",
+	}
+	RegexReplaceFunction = Function{
+		Name:                "regexReplace",
+		Arity:               3,
+		ContextFunctionImpl: regexReplaceImpl,
+		ParamsType:          []uint{datatype.StringType, datatype.StringType, datatype.StringType},
+		Params:              []string{"text", "pattern", "replacement"},
+		ReturnType:          datatype.StringType,
+		Documentation:       "Replaces all matches of a regular expression, $1 in replacement refers to the capture group.\n Returns a text string.",
+	}
+)
+
+//RegexFunctions names, their pattern argument can be validated when it is literal
+var RegexFunctions = map[string]bool{
+	RegexMatchFunction.Name:      true,
+	RegexExtractFunction.Name:    true,
+	RegexExtractAllFunction.Name: true,
+	RegexReplaceFunction.Name:    true,
+}
@@ -0,0 +1,77 @@
+package function
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestRegexCache_Compile(t *testing.T) {
+	cache := NewRegexCache()
+	first, err := cache.Compile(`^ACME-\d+$`)
+	if err != nil {
+		t.Fatalf("RegexCache.Compile() error = %v", err)
+	}
+	second, _ := cache.Compile(`^ACME-\d+$`)
+	if first != second {
+		t.Errorf("RegexCache.Compile() compiled same pattern twice")
+	}
+	if _, err := cache.Compile(`(`); err == nil {
+		t.Errorf("RegexCache.Compile() expected error for invalid pattern")
+	}
+}
+
+func TestRegexCache_Eviction(t *testing.T) {
+	cache := NewRegexCacheWithSize(2)
+	a, _ := cache.Compile(`a`)
+	b, _ := cache.Compile(`b`)
+	if again, _ := cache.Compile(`a`); again != a {
+		t.Errorf("RegexCache.Compile() compiled cached pattern again")
+	}
+	cache.Compile(`c`)
+	if cache.Len() != 2 {
+		t.Errorf("RegexCache.Len() = %d, want 2", cache.Len())
+	}
+	if again, _ := cache.Compile(`a`); again != a {
+		t.Errorf("RegexCache.Compile() evicted recently used pattern")
+	}
+	if again, _ := cache.Compile(`b`); again == b {
+		t.Errorf("RegexCache.Compile() kept least recently used pattern")
+	}
+	for i := 0; i < 10; i++ {
+		cache.Compile(fmt.Sprintf("p%d", i))
+	}
+	if cache.Len() != 2 {
+		t.Errorf("RegexCache.Len() = %d, want 2", cache.Len())
+	}
+}
+
+func TestRegexFunctions(t *testing.T) {
+	ctx := &Context{Regex: NewRegexCache()}
+	tests := []struct {
+		name string
+		impl func(ctx *Context, args []interface{}) (interface{}, error)
+		args []interface{}
+		want interface{}
+	}{
+		{name: "match", impl: regexMatchImpl, args: []interface{}{"ACME-42", `^ACME-\d+$`}, want: true},
+		{name: "no match", impl: regexMatchImpl, args: []interface{}{"ACME-x", `^ACME-\d+$`}, want: false},
+		{name: "extract", impl: regexExtractImpl, args: []interface{}{"id: 42, 7", `\d+`}, want: "42"},
+		{name: "extract group", impl: regexExtractImpl, args: []interface{}{"user@example.com", `@(\w+)`}, want: "example"},
+		{name: "extract nothing", impl: regexExtractImpl, args: []interface{}{"abc", `\d`}, want: nil},
+		{name: "extract all", impl: regexExtractAllImpl, args: []interface{}{"a1b22c333", `\d+`}, want: []interface{}{"1", "22", "333"}},
+		{name: "replace", impl: regexReplaceImpl, args: []interface{}{"2020-10-18", `(\d+)-(\d+)-(\d+)`, "$3/$2/$1"}, want: "18/10/2020"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.impl(ctx, tt.args)
+			if err != nil {
+				t.Errorf("%s error = %v", tt.name, err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package function
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/5anthosh/chili/diagnostic"
+	"github.com/5anthosh/chili/evaluator/datatype"
+	"github.com/5anthosh/chili/parser/token"
+)
+
+//Types is union of datatypes accepted by a parameter, empty Types accepts any datatype
+type Types []uint
+
+//Accepts tells whether value is one of the datatypes
+func (t Types) Accepts(value interface{}) bool {
+	if len(t) == 0 {
+		return true
+	}
+	for _, dtype := range t {
+		if datatype.Checkdatatype(value, dtype) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t Types) String() string {
+	if len(t) == 0 {
+		return datatype.TypeString(datatype.GenerictType)
+	}
+	names := make([]string, len(t))
+	for i, dtype := range t {
+		names[i] = datatype.TypeString(dtype)
+	}
+	return strings.Join(names, "|")
+}
+
+//acceptsOnly tells whether dtype is accepted and GENERIC is not
+func (t Types) acceptsOnly(dtype uint) bool {
+	for _, accepted := range t {
+		if accepted == datatype.GenerictType {
+			return false
+		}
+	}
+	for _, accepted := range t {
+		if accepted == dtype {
+			return true
+		}
+	}
+	return false
+}
+
+//Signature is one way of calling the function, overloaded function has many of them
+type Signature struct {
+	//Params are names of the parameters in error messages
+	Params []string
+	//ParamsType of the parameters
+	ParamsType []Types
+	//Variadic signature repeats the last parameter zero or more times
+	Variadic bool
+	//MaxArity of variadic signature, MaximumNumberOfParamsLimit when zero
+	MaxArity uint
+	//FunctionImpl and ContextFunctionImpl of the overload, implementation of
+	//the function is used when both are nil
+	FunctionImpl        func(args []interface{}) (interface{}, error)
+	ContextFunctionImpl func(ctx *Context, args []interface{}) (interface{}, error)
+}
+
+func (s *Signature) minArity() int {
+	if s.Variadic {
+		return len(s.ParamsType) - 1
+	}
+	return len(s.ParamsType)
+}
+
+func (s *Signature) maxArity() int {
+	if !s.Variadic {
+		return len(s.ParamsType)
+	}
+	if s.MaxArity == 0 {
+		return MaximumNumberOfParamsLimit
+	}
+	return int(s.MaxArity)
+}
+
+func (s *Signature) acceptsArity(n int) bool {
+	return n >= s.minArity() && n <= s.maxArity()
+}
+
+//typeAt is type of argument at position i, the last type repeats for variadic signature
+func (s *Signature) typeAt(i int) Types {
+	if i >= len(s.ParamsType) {
+		if !s.Variadic || len(s.ParamsType) == 0 {
+			return nil
+		}
+		return s.ParamsType[len(s.ParamsType)-1]
+	}
+	return s.ParamsType[i]
+}
+
+//param describes argument at position i as 1st (name)
+func (s *Signature) param(i int) string {
+	position := i + 1
+	if len(s.Params) == 0 {
+		return fmt.Sprintf("argument %d", position)
+	}
+	name := s.Params[len(s.Params)-1]
+	if i < len(s.Params) {
+		name = s.Params[i]
+	}
+	return fmt.Sprintf("argument %d (%s)", position, name)
+}
+
+//accept checks types of the arguments, RANGE is converted to LIST where only LIST
+//is accepted. Index of the first argument which is not accepted is returned, -1 when all are
+func (s *Signature) accept(arguments []interface{}) ([]interface{}, int, error) {
+	accepted := arguments
+	copied := false
+	for i, arg := range arguments {
+		types := s.typeAt(i)
+		if r, ok := arg.(datatype.Range); ok && types.acceptsOnly(datatype.ListType) {
+			list, err := r.List()
+			if err != nil {
+				return nil, i, err
+			}
+			if !copied {
+				accepted = append([]interface{}(nil), arguments...)
+				copied = true
+			}
+			accepted[i] = list
+			continue
+		}
+		if !types.Accepts(arg) {
+			return nil, i, nil
+		}
+	}
+	return accepted, -1, nil
+}
+
+//Format signature of function name like join(delimiter STRING, values ...STRING)
+func (s *Signature) Format(name string) string {
+	params := make([]string, len(s.ParamsType))
+	for i, types := range s.ParamsType {
+		param := types.String()
+		if s.Variadic && i == len(s.ParamsType)-1 {
+			param = "..." + param
+		}
+		if i < len(s.Params) {
+			param = s.Params[i] + " " + param
+		}
+		params[i] = param
+	}
+	return fmt.Sprintf("%s(%s)", name, strings.Join(params, ", "))
+}
+
+//signatures of the function, function without Signatures has one which is made
+//of Arity, MinArity, MaxArity and ParamsType
+func (f *Function) signatures() []Signature {
+	if len(f.Signatures) > 0 {
+		return f.Signatures
+	}
+	signature := Signature{
+		Params:              f.Params,
+		FunctionImpl:        f.FunctionImpl,
+		ContextFunctionImpl: f.ContextFunctionImpl,
+	}
+	if f.Arity != -1 {
+		signature.ParamsType = make([]Types, f.Arity)
+		for i := range signature.ParamsType {
+			if i < len(f.ParamsType) {
+				signature.ParamsType[i] = Types{f.ParamsType[i]}
+			}
+		}
+		return []Signature{signature}
+	}
+	//variadic function takes MinArity arguments followed by any number of
+	//arguments of the last type in ParamsType
+	signature.Variadic = true
+	signature.MaxArity = f.MaxArity
+	signature.ParamsType = make([]Types, f.MinArity+1)
+	for i := range signature.ParamsType {
+		if len(f.ParamsType) == 0 {
+			continue
+		}
+		if i < len(f.ParamsType) {
+			signature.ParamsType[i] = Types{f.ParamsType[i]}
+			continue
+		}
+		signature.ParamsType[i] = Types{f.ParamsType[len(f.ParamsType)-1]}
+	}
+	return []Signature{signature}
+}
+
+//Resolve finds the first signature which accepts the arguments, implementation of
+//the function is filled in the signature when the overload has none.
+//Arguments are returned with RANGE converted to LIST where LIST is expected
+func (f *Function) Resolve(arguments []interface{}) (Signature, []interface{}, error) {
+	signatures := f.signatures()
+	for _, signature := range signatures {
+		if !signature.acceptsArity(len(arguments)) {
+			continue
+		}
+		accepted, failed, err := signature.accept(arguments)
+		if err != nil {
+			return Signature{}, nil, err
+		}
+		if failed != -1 {
+			continue
+		}
+		if signature.FunctionImpl == nil && signature.ContextFunctionImpl == nil {
+			signature.FunctionImpl = f.FunctionImpl
+			signature.ContextFunctionImpl = f.ContextFunctionImpl
+		}
+		return signature, accepted, nil
+	}
+	if len(signatures) == 1 {
+		return Signature{}, nil, f.signatureErr(&signatures[0], arguments)
+	}
+	return Signature{}, nil, f.overloadErr(signatures, arguments)
+}
+
+//signatureErr tells which argument is not accepted by the only signature of the function
+func (f *Function) signatureErr(signature *Signature, arguments []interface{}) error {
+	if !signature.acceptsArity(len(arguments)) {
+		return f.arityErr(signature, len(arguments))
+	}
+	_, failed, _ := signature.accept(arguments)
+	return diagnostic.NewTypeError(token.Span{}, "%s() %s expects %s but got %s", f.Name, signature.param(failed), signature.typeAt(failed), datatype.GetTypeString(arguments[failed]))
+}
+
+func (f *Function) arityErr(signature *Signature, n int) error {
+	if !signature.Variadic {
+		return diagnostic.NewArityError(token.Span{}, f.Name, "%s() expecting %d arguments but got %d", f.Name, signature.minArity(), n)
+	}
+	if n < signature.minArity() {
+		return diagnostic.NewArityError(token.Span{}, f.Name, "%s() expecting minimum %d arguments", f.Name, signature.minArity())
+	}
+	return diagnostic.NewArityError(token.Span{}, f.Name, "%s() expecting maximum %d arguments", f.Name, signature.maxArity())
+}
+
+//overloadErr lists the candidate signatures when none of them accepts the arguments
+func (f *Function) overloadErr(signatures []Signature, arguments []interface{}) error {
+	types := make([]string, len(arguments))
+	for i, arg := range arguments {
+		types[i] = datatype.GetTypeString(arg)
+	}
+	candidates := make([]string, len(signatures))
+	arity := false
+	for i := range signatures {
+		candidates[i] = signatures[i].Format(f.Name)
+		arity = arity || signatures[i].acceptsArity(len(arguments))
+	}
+	message := "%s() has no signature for (%s), candidates are %s"
+	if !arity {
+		return diagnostic.NewArityError(token.Span{}, f.Name, message, f.Name, strings.Join(types, ", "), strings.Join(candidates, ", "))
+	}
+	return diagnostic.NewTypeError(token.Span{}, message, f.Name, strings.Join(types, ", "), strings.Join(candidates, ", "))
+}
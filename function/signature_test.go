@@ -0,0 +1,112 @@
+package function
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/5anthosh/chili/diagnostic"
+	"github.com/5anthosh/chili/evaluator/datatype"
+	"github.com/5anthosh/chili/parser/token"
+	"github.com/shopspring/decimal"
+)
+
+func TestFunction_Resolve(t *testing.T) {
+	number := decimal.NewFromInt(1)
+	format := Function{
+		Name: "format",
+		Signatures: []Signature{
+			{
+				Params:     []string{"pattern", "values"},
+				ParamsType: []Types{{datatype.StringType}, {datatype.NumberType}},
+				Variadic:   true,
+			},
+			{
+				Params:     []string{"value", "places"},
+				ParamsType: []Types{{datatype.NumberType, datatype.BooleanType}, {datatype.NumberType}},
+			},
+		},
+	}
+	legacy := Function{Name: "legacy", Arity: -1, MinArity: 1, MaxArity: 3, ParamsType: []uint{datatype.StringType, datatype.NumberType}}
+	tests := []struct {
+		name      string
+		function  Function
+		args      []interface{}
+		want      int
+		wantErr   string
+		wantArity bool
+	}{
+		{name: "variadic tail", function: format, args: []interface{}{"x", number, number}, want: 0},
+		{name: "variadic empty tail", function: format, args: []interface{}{"x"}, want: 0},
+		{name: "union", function: format, args: []interface{}{true, number}, want: 1},
+		{name: "overload", function: format, args: []interface{}{number, number}, want: 1},
+		{
+			name:     "no overload",
+			function: format,
+			args:     []interface{}{"x", "y"},
+			wantErr:  "format() has no signature for (STRING, STRING), candidates are format(pattern STRING, values ...NUMBER), format(value NUMBER|BOOLEAN, places NUMBER)",
+		},
+		{
+			name:      "no overload arity",
+			function:  format,
+			args:      []interface{}{},
+			wantErr:   "format() has no signature for (), candidates are format(pattern STRING, values ...NUMBER), format(value NUMBER|BOOLEAN, places NUMBER)",
+			wantArity: true,
+		},
+		{name: "legacy", function: legacy, args: []interface{}{"x", number, number}, want: 0},
+		{
+			name:     "legacy variadic type",
+			function: legacy,
+			args:     []interface{}{"x", number, "y"},
+			wantErr:  "legacy() argument 3 expects NUMBER but got STRING",
+		},
+		{
+			name:      "legacy maximum",
+			function:  legacy,
+			args:      []interface{}{"x", number, number, number},
+			wantErr:   "legacy() expecting maximum 3 arguments",
+			wantArity: true,
+		},
+		{
+			name:     "named parameter",
+			function: RoundFunction,
+			args:     []interface{}{number, "2"},
+			wantErr:  "round() argument 2 (places) expects NUMBER but got STRING",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _, err := tt.function.Resolve(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("Function.Resolve() error = %v, want %v", err, tt.wantErr)
+				}
+				var arityErr *diagnostic.ArityError
+				var typeErr *diagnostic.TypeError
+				if tt.wantArity && !errors.As(err, &arityErr) || !tt.wantArity && !errors.As(err, &typeErr) {
+					t.Errorf("Function.Resolve() error = %T, wantArity %v", err, tt.wantArity)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Function.Resolve() error = %v", err)
+			}
+			if want := tt.function.Signatures; want != nil && got.Format(tt.function.Name) != want[tt.want].Format(tt.function.Name) {
+				t.Errorf("Function.Resolve() = %s, want %s", got.Format(tt.function.Name), want[tt.want].Format(tt.function.Name))
+			}
+		})
+	}
+}
+
+func TestDefaultFunctionsParams(t *testing.T) {
+	for _, function := range DefaultFunctions {
+		params := append([]string{}, function.Params...)
+		for _, signature := range function.Signatures {
+			params = append(params, signature.Params...)
+		}
+		for _, param := range params {
+			if token.IsKeyword(param) {
+				t.Errorf("%s() parameter %s is a keyword and cannot be named in calls", function.Name, param)
+			}
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package operator
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/5anthosh/chili/parser/token"
+)
+
+//Precedence levels of builtin operators from the loosest to the tightest,
+//custom operator can take a level in between, ternary is looser than all of them
+const (
+	PrecedenceCoalesce       = 10
+	PrecedenceOr             = 20
+	PrecedenceAnd            = 30
+	PrecedenceEquality       = 40
+	PrecedenceComparison     = 50
+	PrecedencePipe           = 60
+	PrecedenceBitwiseOr      = 70
+	PrecedenceBitwiseXor     = 80
+	PrecedenceBitwiseAnd     = 90
+	PrecedenceShift          = 100
+	PrecedenceRange          = 110
+	PrecedenceAdditive       = 120
+	PrecedenceMultiplicative = 130
+	PrecedenceExponent       = 140
+	PrecedencePrefix         = 150
+)
+
+//Kind of operator
+const (
+	//Infix operator between two operands, a ** b
+	Infix = iota
+	//Prefix operator before the operand, ~a
+	Prefix
+)
+
+//symbolChars are characters allowed in punctuation symbols
+const symbolChars = "+-*/%^=!<>&|~$"
+
+//Operator is custom operator implemented in Go
+type Operator struct {
+	//Symbol is punctuation like ** or ~= or keyword like contains
+	Symbol string
+	Kind   uint
+	//Precedence between PrecedenceCoalesce and PrecedencePrefix,
+	//operand of prefix operator binds as tight as the precedence
+	Precedence       uint
+	RightAssociative bool
+	//Impl gets one operand for prefix and two for infix operator
+	Impl func(operands []interface{}) (interface{}, error)
+}
+
+//Table of custom operators
+type Table struct {
+	infix  map[string]Operator
+	prefix map[string]Operator
+	//symbols are punctuation symbols, longest first
+	symbols []string
+}
+
+//NewTable creates empty operator table
+func NewTable() *Table {
+	return &Table{
+		infix:  make(map[string]Operator),
+		prefix: make(map[string]Operator),
+	}
+}
+
+//Add operator to the table
+func (t *Table) Add(op Operator) error {
+	err := checkSymbol(op.Symbol)
+	if err != nil {
+		return err
+	}
+	if op.Precedence < PrecedenceCoalesce || op.Precedence > PrecedencePrefix {
+		return fmt.Errorf("precedence of operator %s must be between %d and %d", op.Symbol, PrecedenceCoalesce, PrecedencePrefix)
+	}
+	if op.Impl == nil {
+		return fmt.Errorf("operator %s has no implementation", op.Symbol)
+	}
+	operators := t.infix
+	if op.Kind == Prefix {
+		operators = t.prefix
+	}
+	if _, ok := operators[op.Symbol]; ok {
+		return fmt.Errorf("operator %s is already declared", op.Symbol)
+	}
+	known := t.Has(op.Symbol)
+	operators[op.Symbol] = op
+	if !isKeyword(op.Symbol) && !known {
+		t.symbols = append(t.symbols, op.Symbol)
+		sort.SliceStable(t.symbols, func(i, j int) bool {
+			return len(t.symbols[i]) > len(t.symbols[j])
+		})
+	}
+	return nil
+}
+
+//Infix operator of the symbol
+func (t *Table) Infix(symbol string) (Operator, bool) {
+	if t == nil {
+		return Operator{}, false
+	}
+	op, ok := t.infix[symbol]
+	return op, ok
+}
+
+//Prefix operator of the symbol
+func (t *Table) Prefix(symbol string) (Operator, bool) {
+	if t == nil {
+		return Operator{}, false
+	}
+	op, ok := t.prefix[symbol]
+	return op, ok
+}
+
+//Has tells whether symbol is infix or prefix operator
+func (t *Table) Has(symbol string) bool {
+	_, infix := t.Infix(symbol)
+	_, prefix := t.Prefix(symbol)
+	return infix || prefix
+}
+
+//Match returns the longest punctuation symbol at start of source
+func (t *Table) Match(source []byte) string {
+	if t == nil {
+		return ""
+	}
+	for _, symbol := range t.symbols {
+		if bytes.HasPrefix(source, []byte(symbol)) {
+			return symbol
+		}
+	}
+	return ""
+}
+
+func checkSymbol(symbol string) error {
+	if symbol == "" {
+		return fmt.Errorf("operator symbol is empty")
+	}
+	if token.IsReserved(symbol) {
+		return fmt.Errorf("%s is reserved", symbol)
+	}
+	if isKeyword(symbol) {
+		return nil
+	}
+	for _, c := range symbol {
+		if !strings.ContainsRune(symbolChars, c) {
+			return fmt.Errorf("operator %s must be a keyword or made of %s", symbol, symbolChars)
+		}
+	}
+	return nil
+}
+
+func isKeyword(symbol string) bool {
+	for i, c := range symbol {
+		letter := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+		if !letter && (i == 0 || c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package operator
+
+import "testing"
+
+func identity(operands []interface{}) (interface{}, error) {
+	return operands[0], nil
+}
+
+func TestTable_Add(t *testing.T) {
+	tests := []struct {
+		name    string
+		op      Operator
+		wantErr bool
+	}{
+		{name: "punctuation", op: Operator{Symbol: "**", Precedence: PrecedenceExponent, Impl: identity}},
+		{name: "keyword", op: Operator{Symbol: "contains", Precedence: PrecedenceComparison, Impl: identity}},
+		{name: "prefix", op: Operator{Symbol: "$", Kind: Prefix, Precedence: PrecedencePrefix, Impl: identity}},
+		{name: "builtin", op: Operator{Symbol: "&&", Precedence: PrecedenceAnd, Impl: identity}, wantErr: true},
+		{name: "keyword reserved", op: Operator{Symbol: "in", Precedence: PrecedenceComparison, Impl: identity}, wantErr: true},
+		{name: "quoted identifier", op: Operator{Symbol: "@", Kind: Prefix, Precedence: PrecedencePrefix, Impl: identity}, wantErr: true},
+		{name: "invalid character", op: Operator{Symbol: "(+", Precedence: PrecedenceAdditive, Impl: identity}, wantErr: true},
+		{name: "precedence", op: Operator{Symbol: "<>", Precedence: 0, Impl: identity}, wantErr: true},
+		{name: "no implementation", op: Operator{Symbol: "<>", Precedence: PrecedenceEquality}, wantErr: true},
+	}
+	table := NewTable()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := table.Add(tt.op); (err != nil) != tt.wantErr {
+				t.Errorf("Table.Add() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+	if err := table.Add(Operator{Symbol: "**", Precedence: PrecedenceExponent, Impl: identity}); err == nil {
+		t.Errorf("Table.Add() expected error for duplicate operator")
+	}
+}
+
+func TestTable_Match(t *testing.T) {
+	table := NewTable()
+	for _, symbol := range []string{"*~", "*~*", "contains"} {
+		if err := table.Add(Operator{Symbol: symbol, Precedence: PrecedenceComparison, Impl: identity}); err != nil {
+			t.Fatalf("Table.Add() error = %v", err)
+		}
+	}
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"*~* 2", "*~*"},
+		{"*~ 2", "*~"},
+		{"* 2", ""},
+		{"contains", ""},
+	}
+	for _, tt := range tests {
+		if got := table.Match([]byte(tt.source)); got != tt.want {
+			t.Errorf("Table.Match(%q) = %q, want %q", tt.source, got, tt.want)
+		}
+	}
+	var empty *Table
+	if empty.Has("**") || empty.Match([]byte("**")) != "" {
+		t.Errorf("nil Table must be empty")
+	}
+}
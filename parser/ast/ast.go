@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/5anthosh/chili/parser/ast/expr"
+	"github.com/5anthosh/chili/parser/ast/stmt"
 )
 
 const (
@@ -89,13 +90,25 @@ func (ac *Printer) VisitVariableExpr(variableExpression *expr.Variable) (interfa
 //VisitFunctionCall #
 func (ac *Printer) VisitFunctionCall(functionCallExpr *expr.FunctionCall) (interface{}, error) {
 	var builder strings.Builder
-	builder.WriteString(fmt.Sprintf("%s %s\n|\n", createPrefix(ac.depth, "FUNCTION"), functionCallExpr.Name))
+	kind := "FUNCTION"
+	if functionCallExpr.Piped {
+		kind = "PIPED FUNCTION"
+	}
+	builder.WriteString(fmt.Sprintf("%s %s\n|\n", createPrefix(ac.depth, kind), functionCallExpr.Name))
 	ac.depth += tab
-	for _, arg := range functionCallExpr.Args {
+	for i, arg := range functionCallExpr.Args {
+		named := functionCallExpr.Names != nil && functionCallExpr.Names[i] != ""
+		if named {
+			builder.WriteString(fmt.Sprintf("%s %s\n|\n", createPrefix(ac.depth, "NAMED"), functionCallExpr.Names[i]))
+			ac.depth += tab
+		}
 		argStr, err := ac.accept(arg)
 		if err != nil {
 			return nil, err
 		}
+		if named {
+			ac.depth -= tab
+		}
 		builder.WriteString(fmt.Sprintf("%s", argStr))
 	}
 	ac.depth -= tab
@@ -137,6 +150,218 @@ func (ac *Printer) VisitLogicalExpr(logicalExpr *expr.Logical) (interface{}, err
 	return fmt.Sprintf("%s %s \n|\n%v%v", createPrefix(ac.depth, "LOGICAL"), logicalExpr.Operator.Type.String(), left, right), nil
 }
 
+//VisitListExpr #
+func (ac *Printer) VisitListExpr(listExpr *expr.List) (interface{}, error) {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("%s \n|\n", createPrefix(ac.depth, "LIST")))
+	ac.depth += tab
+	for _, element := range listExpr.Elements {
+		elementStr, err := ac.accept(element)
+		if err != nil {
+			return nil, err
+		}
+		builder.WriteString(fmt.Sprintf("%s", elementStr))
+	}
+	ac.depth -= tab
+	return builder.String(), nil
+}
+
+//VisitIndexExpr #
+func (ac *Printer) VisitIndexExpr(indexExpr *expr.Index) (interface{}, error) {
+	ac.depth += tab
+	object, err := ac.accept(indexExpr.Object)
+	if err != nil {
+		return nil, err
+	}
+	index, err := ac.accept(indexExpr.Index)
+	if err != nil {
+		return nil, err
+	}
+	ac.depth -= tab
+	return fmt.Sprintf("%s \n|\n%v%v", createPrefix(ac.depth, "INDEX"), object, index), nil
+}
+
+//VisitMapExpr #
+func (ac *Printer) VisitMapExpr(mapExpr *expr.Map) (interface{}, error) {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("%s \n|\n", createPrefix(ac.depth, "MAP")))
+	ac.depth += tab
+	for i, key := range mapExpr.Keys {
+		builder.WriteString(fmt.Sprintf("%s %s\n|\n", createPrefix(ac.depth, "KEY"), key))
+		ac.depth += tab
+		value, err := ac.accept(mapExpr.Values[i])
+		if err != nil {
+			return nil, err
+		}
+		ac.depth -= tab
+		builder.WriteString(fmt.Sprintf("%s", value))
+	}
+	ac.depth -= tab
+	return builder.String(), nil
+}
+
+//VisitMemberExpr #
+func (ac *Printer) VisitMemberExpr(memberExpr *expr.Member) (interface{}, error) {
+	ac.depth += tab
+	object, err := ac.accept(memberExpr.Object)
+	if err != nil {
+		return nil, err
+	}
+	ac.depth -= tab
+	kind := "MEMBER"
+	if memberExpr.Optional {
+		kind = "OPTIONAL MEMBER"
+	}
+	return fmt.Sprintf("%s %s \n|\n%v", createPrefix(ac.depth, kind), memberExpr.Name, object), nil
+}
+
+//VisitLambdaExpr #
+func (ac *Printer) VisitLambdaExpr(lambdaExpr *expr.Lambda) (interface{}, error) {
+	ac.depth += tab
+	body, err := ac.accept(lambdaExpr.Body)
+	if err != nil {
+		return nil, err
+	}
+	ac.depth -= tab
+	return fmt.Sprintf("%s (%s) \n|\n%v", createPrefix(ac.depth, "LAMBDA"), strings.Join(lambdaExpr.Params, ", "), body), nil
+}
+
+//VisitTemplateExpr #
+func (ac *Printer) VisitTemplateExpr(templateExpr *expr.Template) (interface{}, error) {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("%s \n|\n", createPrefix(ac.depth, "TEMPLATE")))
+	ac.depth += tab
+	for _, part := range templateExpr.Parts {
+		partStr, err := ac.accept(part)
+		if err != nil {
+			return nil, err
+		}
+		builder.WriteString(fmt.Sprintf("%s", partStr))
+	}
+	ac.depth -= tab
+	return builder.String(), nil
+}
+
+//VisitLetExpr #
+func (ac *Printer) VisitLetExpr(letExpr *expr.Let) (interface{}, error) {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("%s \n|\n", createPrefix(ac.depth, "LET")))
+	ac.depth += tab
+	for i, name := range letExpr.Names {
+		builder.WriteString(fmt.Sprintf("%s %s\n|\n", createPrefix(ac.depth, "BINDING"), name))
+		ac.depth += tab
+		value, err := ac.accept(letExpr.Values[i])
+		if err != nil {
+			return nil, err
+		}
+		ac.depth -= tab
+		builder.WriteString(fmt.Sprintf("%s", value))
+	}
+	body, err := ac.accept(letExpr.Body)
+	if err != nil {
+		return nil, err
+	}
+	ac.depth -= tab
+	builder.WriteString(fmt.Sprintf("%s", body))
+	return builder.String(), nil
+}
+
+//VisitBadExpr #
+func (ac *Printer) VisitBadExpr(badExpr *expr.Bad) (interface{}, error) {
+	return fmt.Sprintf("%s %s\n|\n", createPrefix(ac.depth, "BAD"), badExpr.Location.Start.String()), nil
+}
+
+//VisitCaseExpr #
+func (ac *Printer) VisitCaseExpr(caseExpr *expr.Case) (interface{}, error) {
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("%s \n|\n", createPrefix(ac.depth, "CASE")))
+	ac.depth += tab
+	if caseExpr.Subject != nil {
+		subject, err := ac.accept(caseExpr.Subject)
+		if err != nil {
+			return nil, err
+		}
+		builder.WriteString(fmt.Sprintf("%s", subject))
+	}
+	for i, condition := range caseExpr.Conditions {
+		builder.WriteString(fmt.Sprintf("%s \n|\n", createPrefix(ac.depth, "WHEN")))
+		ac.depth += tab
+		conditionStr, err := ac.accept(condition)
+		if err != nil {
+			return nil, err
+		}
+		value, err := ac.accept(caseExpr.Values[i])
+		if err != nil {
+			return nil, err
+		}
+		ac.depth -= tab
+		builder.WriteString(fmt.Sprintf("%s%s", conditionStr, value))
+	}
+	if caseExpr.Else != nil {
+		builder.WriteString(fmt.Sprintf("%s \n|\n", createPrefix(ac.depth, "ELSE")))
+		ac.depth += tab
+		value, err := ac.accept(caseExpr.Else)
+		if err != nil {
+			return nil, err
+		}
+		ac.depth -= tab
+		builder.WriteString(fmt.Sprintf("%s", value))
+	}
+	ac.depth -= tab
+	return builder.String(), nil
+}
+
+//VisitSpreadExpr #
+func (ac *Printer) VisitSpreadExpr(spreadExpr *expr.Spread) (interface{}, error) {
+	ac.depth += tab
+	expression, err := ac.accept(spreadExpr.Expression)
+	if err != nil {
+		return nil, err
+	}
+	ac.depth -= tab
+	return fmt.Sprintf("%s \n|\n%v", createPrefix(ac.depth, "SPREAD"), expression), nil
+}
+
+//VisitExpressionStmt #
+func (ac *Printer) VisitExpressionStmt(expressionStmt *stmt.Expression) (interface{}, error) {
+	return ac.accept(expressionStmt.Expression)
+}
+
+//VisitAssignStmt #
+func (ac *Printer) VisitAssignStmt(assignStmt *stmt.Assign) (interface{}, error) {
+	ac.depth += tab
+	value, err := ac.accept(assignStmt.Value)
+	if err != nil {
+		return nil, err
+	}
+	ac.depth -= tab
+	return fmt.Sprintf("%s %s \n|\n%v", createPrefix(ac.depth, "ASSIGN"), assignStmt.Name, value), nil
+}
+
+//VisitDefStmt #
+func (ac *Printer) VisitDefStmt(defStmt *stmt.Def) (interface{}, error) {
+	ac.depth += tab
+	body, err := ac.accept(defStmt.Body)
+	if err != nil {
+		return nil, err
+	}
+	ac.depth -= tab
+	return fmt.Sprintf("%s %s(%s) \n|\n%v", createPrefix(ac.depth, "DEF"), defStmt.Name, strings.Join(defStmt.Params, ", "), body), nil
+}
+
+//PrintScript prints ast structure of statements
+func (ac *Printer) PrintScript(statements []stmt.Stmt) (string, error) {
+	var builder strings.Builder
+	for _, statement := range statements {
+		value, err := statement.Accept(ac)
+		if err != nil {
+			return "", err
+		}
+		builder.WriteString(value.(string))
+	}
+	return builder.String(), nil
+}
+
 func (ac *Printer) accept(expression expr.Expr) (interface{}, error) {
 	return expression.Accept(ac)
 }
@@ -5,6 +5,8 @@ import "github.com/5anthosh/chili/parser/token"
 //Expr interface
 type Expr interface {
 	Accept(Visitor) (interface{}, error)
+	//Span of the source which the node is parsed from
+	Span() token.Span
 }
 
 //Visitor interface
@@ -17,6 +19,16 @@ type Visitor interface {
 	VisitFunctionCall(functionCallExpr *FunctionCall) (interface{}, error)
 	VisitTernary(ternaryExpr *Ternary) (interface{}, error)
 	VisitLogicalExpr(logicalExpr *Logical) (interface{}, error)
+	VisitListExpr(listExpr *List) (interface{}, error)
+	VisitIndexExpr(indexExpr *Index) (interface{}, error)
+	VisitMapExpr(mapExpr *Map) (interface{}, error)
+	VisitMemberExpr(memberExpr *Member) (interface{}, error)
+	VisitLambdaExpr(lambdaExpr *Lambda) (interface{}, error)
+	VisitTemplateExpr(templateExpr *Template) (interface{}, error)
+	VisitLetExpr(letExpr *Let) (interface{}, error)
+	VisitBadExpr(badExpr *Bad) (interface{}, error)
+	VisitCaseExpr(caseExpr *Case) (interface{}, error)
+	VisitSpreadExpr(spreadExpr *Spread) (interface{}, error)
 }
 
 //Binary #
@@ -24,6 +36,7 @@ type Binary struct {
 	Left     Expr
 	Right    Expr
 	Operator *token.Token
+	Location token.Span
 }
 
 //Accept binary operation
@@ -31,9 +44,15 @@ func (b *Binary) Accept(visitor Visitor) (interface{}, error) {
 	return visitor.VisitBinaryExpr(b)
 }
 
+//Span #
+func (b *Binary) Span() token.Span {
+	return b.Location
+}
+
 //Group #
 type Group struct {
 	Expression Expr
+	Location   token.Span
 }
 
 //Accept Group exp
@@ -41,9 +60,15 @@ func (g *Group) Accept(visitor Visitor) (interface{}, error) {
 	return visitor.VisitGroupExpr(g)
 }
 
+//Span #
+func (g *Group) Span() token.Span {
+	return g.Location
+}
+
 //Literal #
 type Literal struct {
-	Value interface{}
+	Value    interface{}
+	Location token.Span
 }
 
 //Accept Literal expression
@@ -51,10 +76,16 @@ func (l *Literal) Accept(visitor Visitor) (interface{}, error) {
 	return visitor.VisitLiteralExpr(l)
 }
 
+//Span #
+func (l *Literal) Span() token.Span {
+	return l.Location
+}
+
 //Unary #
 type Unary struct {
 	Operator *token.Token
 	Right    Expr
+	Location token.Span
 }
 
 //Accept Unary expr
@@ -62,9 +93,15 @@ func (u *Unary) Accept(visitor Visitor) (interface{}, error) {
 	return visitor.VisitUnaryExpr(u)
 }
 
+//Span #
+func (u *Unary) Span() token.Span {
+	return u.Location
+}
+
 //Variable #
 type Variable struct {
-	Name string
+	Name     string
+	Location token.Span
 }
 
 //Accept variable expression
@@ -72,10 +109,21 @@ func (v *Variable) Accept(visitor Visitor) (interface{}, error) {
 	return visitor.VisitVariableExpr(v)
 }
 
+//Span #
+func (v *Variable) Span() token.Span {
+	return v.Location
+}
+
 //FunctionCall #
 type FunctionCall struct {
 	Name string
 	Args []Expr
+	//Names of arguments aligned with Args, empty for positional argument,
+	//nil when all arguments are positional
+	Names []string
+	//Piped call x |> f(y), x is the first argument
+	Piped    bool
+	Location token.Span
 }
 
 //Accept #
@@ -83,11 +131,17 @@ func (f *FunctionCall) Accept(visitor Visitor) (interface{}, error) {
 	return visitor.VisitFunctionCall(f)
 }
 
+//Span #
+func (f *FunctionCall) Span() token.Span {
+	return f.Location
+}
+
 //Ternary  #
 type Ternary struct {
 	Condition Expr
 	True      Expr
 	False     Expr
+	Location  token.Span
 }
 
 //Accept #
@@ -95,14 +149,196 @@ func (t *Ternary) Accept(visitor Visitor) (interface{}, error) {
 	return visitor.VisitTernary(t)
 }
 
+//Span #
+func (t *Ternary) Span() token.Span {
+	return t.Location
+}
+
 //Logical operation
 type Logical struct {
 	Left     Expr
 	Right    Expr
 	Operator *token.Token
+	Location token.Span
 }
 
 //Accept #
 func (t *Logical) Accept(visitor Visitor) (interface{}, error) {
 	return visitor.VisitLogicalExpr(t)
 }
+
+//Span #
+func (t *Logical) Span() token.Span {
+	return t.Location
+}
+
+//List literal
+type List struct {
+	Elements []Expr
+	Location token.Span
+}
+
+//Accept #
+func (l *List) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitListExpr(l)
+}
+
+//Span #
+func (l *List) Span() token.Span {
+	return l.Location
+}
+
+//Index access xs[i] or obj["field"]
+type Index struct {
+	Object   Expr
+	Index    Expr
+	Location token.Span
+}
+
+//Accept #
+func (i *Index) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitIndexExpr(i)
+}
+
+//Span #
+func (i *Index) Span() token.Span {
+	return i.Location
+}
+
+//Map literal {key: value}
+type Map struct {
+	Keys     []string
+	Values   []Expr
+	Location token.Span
+}
+
+//Accept #
+func (m *Map) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitMapExpr(m)
+}
+
+//Span #
+func (m *Map) Span() token.Span {
+	return m.Location
+}
+
+//Member access obj.field, or obj?.field when Optional
+type Member struct {
+	Object   Expr
+	Name     string
+	Optional bool
+	Location token.Span
+}
+
+//Accept #
+func (m *Member) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitMemberExpr(m)
+}
+
+//Span #
+func (m *Member) Span() token.Span {
+	return m.Location
+}
+
+//Lambda expression (a, b) => body
+type Lambda struct {
+	Params   []string
+	Body     Expr
+	Location token.Span
+}
+
+//Accept #
+func (l *Lambda) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitLambdaExpr(l)
+}
+
+//Span #
+func (l *Lambda) Span() token.Span {
+	return l.Location
+}
+
+//Template string, Parts are string literals and embedded expressions
+type Template struct {
+	Parts    []Expr
+	Location token.Span
+}
+
+//Accept #
+func (t *Template) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitTemplateExpr(t)
+}
+
+//Span #
+func (t *Template) Span() token.Span {
+	return t.Location
+}
+
+//Let binds Names to Values in scope of Body, let a = 1, b = a + 1 in a + b
+type Let struct {
+	Names    []string
+	Values   []Expr
+	Body     Expr
+	Location token.Span
+}
+
+//Accept #
+func (l *Let) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitLetExpr(l)
+}
+
+//Span #
+func (l *Let) Span() token.Span {
+	return l.Location
+}
+
+//Bad is placeholder for source which failed to parse in recovering mode
+type Bad struct {
+	Location token.Span
+}
+
+//Accept #
+func (b *Bad) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitBadExpr(b)
+}
+
+//Span #
+func (b *Bad) Span() token.Span {
+	return b.Location
+}
+
+//Case is case [subject] when condition then value ... [else default] end,
+//without Subject the first truthy condition is taken, otherwise the first
+//condition equal to Subject. Subject and Else are nil when missing
+type Case struct {
+	Subject    Expr
+	Conditions []Expr
+	Values     []Expr
+	Else       Expr
+	Location   token.Span
+}
+
+//Accept #
+func (c *Case) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitCaseExpr(c)
+}
+
+//Span #
+func (c *Case) Span() token.Span {
+	return c.Location
+}
+
+//Spread is ...list argument which is expanded into arguments of function call
+type Spread struct {
+	Expression Expr
+	Location   token.Span
+}
+
+//Accept #
+func (s *Spread) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitSpreadExpr(s)
+}
+
+//Span #
+func (s *Spread) Span() token.Span {
+	return s.Location
+}
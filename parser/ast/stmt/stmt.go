@@ -0,0 +1,71 @@
+package stmt
+
+import (
+	"github.com/5anthosh/chili/parser/ast/expr"
+	"github.com/5anthosh/chili/parser/token"
+)
+
+//Stmt interface
+type Stmt interface {
+	Accept(Visitor) (interface{}, error)
+	//Span of the source which the node is parsed from
+	Span() token.Span
+}
+
+//Visitor interface
+type Visitor interface {
+	VisitExpressionStmt(expressionStmt *Expression) (interface{}, error)
+	VisitAssignStmt(assignStmt *Assign) (interface{}, error)
+	VisitDefStmt(defStmt *Def) (interface{}, error)
+}
+
+//Expression statement
+type Expression struct {
+	Expression expr.Expr
+	Location   token.Span
+}
+
+//Accept #
+func (e *Expression) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitExpressionStmt(e)
+}
+
+//Span #
+func (e *Expression) Span() token.Span {
+	return e.Location
+}
+
+//Assign statement name = value
+type Assign struct {
+	Name     string
+	Value    expr.Expr
+	Location token.Span
+}
+
+//Accept #
+func (a *Assign) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitAssignStmt(a)
+}
+
+//Span #
+func (a *Assign) Span() token.Span {
+	return a.Location
+}
+
+//Def statement def name(a, b) = body
+type Def struct {
+	Name     string
+	Params   []string
+	Body     expr.Expr
+	Location token.Span
+}
+
+//Accept #
+func (d *Def) Accept(visitor Visitor) (interface{}, error) {
+	return visitor.VisitDefStmt(d)
+}
+
+//Span #
+func (d *Def) Span() token.Span {
+	return d.Location
+}
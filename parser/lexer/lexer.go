@@ -5,7 +5,12 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math/big"
+	"strconv"
+	"strings"
 
+	"github.com/5anthosh/chili/diagnostic"
+	"github.com/5anthosh/chili/operator"
 	"github.com/5anthosh/chili/parser/token"
 	"github.com/shopspring/decimal"
 )
@@ -27,9 +32,22 @@ type Lexer struct {
 	// Scanned tokens
 	tokens []token.Token
 	// Current column
-	column  uint
-	start   uint
-	current uint
+	column uint
+	// Current line
+	line uint
+	// Offset where current line starts
+	lineStart uint
+	// Position where current token starts
+	startPosition token.Position
+	// Position of the source when it is part of bigger source
+	base token.Position
+	// Custom operators which are scanned as Operator tokens,
+	// keyword operators only in operator position
+	operators *operator.Table
+	// Comments skipped so far
+	comments []token.Comment
+	start    uint
+	current  uint
 }
 
 //New  creates new lexer
@@ -46,6 +64,7 @@ func FromBytes(source []byte) *Lexer {
 	lex := new(Lexer)
 	lex.source = source
 	lex.len = uint(len(source))
+	lex.line = 1
 	return lex
 }
 
@@ -54,9 +73,28 @@ func FromString(source string) *Lexer {
 	return FromBytes([]byte(source))
 }
 
+//FromStringAt creates lexer for source which starts at base position of bigger source
+func FromStringAt(source string, base token.Position) *Lexer {
+	lex := FromString(source)
+	lex.base = base
+	return lex
+}
+
+//SetOperators scans symbols and keywords of custom operators as Operator tokens
+func (l *Lexer) SetOperators(operators *operator.Table) {
+	l.operators = operators
+}
+
+//Comments skipped by the lexer so far, in source order
+func (l *Lexer) Comments() []token.Comment {
+	return l.comments
+}
+
 //Next token
 func (l *Lexer) Next() (*token.Token, error) {
 	if l.isEnd() {
+		l.start = l.current
+		l.startPosition = l.position(l.current)
 		return l.nextToken(token.EOF{}, nil), nil
 	}
 	l.start = l.current
@@ -69,8 +107,17 @@ func (l *Lexer) Next() (*token.Token, error) {
 }
 
 func (l *Lexer) scan() (*token.Token, error) {
-	b := l.eat()
-	b = l.space(b)
+	b, err := l.skip(l.eat())
+	if err != nil {
+		return nil, err
+	}
+	l.startPosition = l.position(l.start)
+	if symbol := l.operators.Match(l.source[l.start:]); symbol != "" {
+		for i := 1; i < len(symbol); i++ {
+			l.eat()
+		}
+		return l.nextToken(token.Operator{}, nil), nil
+	}
 	switch b {
 	case token.PlusChar:
 		return l.nextToken(token.Plus{}, nil), nil
@@ -79,11 +126,38 @@ func (l *Lexer) scan() (*token.Token, error) {
 	case token.StarChar:
 		return l.nextToken(token.Star{}, nil), nil
 	case token.CommonSlashChar:
+		if l.peek(0) == token.CommonSlashChar {
+			l.eat()
+			//'//' is floor division, without left operand it is likely meant as comment
+			if !l.afterOperand() {
+				return nil, l.syntaxError("'//' is floor division, use # for line comment")
+			}
+			return l.nextToken(token.FloorSlash{}, nil), nil
+		}
 		return l.nextToken(token.CommonSlash{}, nil), nil
 	case token.OpenParenChar:
 		return l.nextToken(token.OpenParen{}, nil), nil
 	case token.CloseParenChar:
 		return l.nextToken(token.CloseParen{}, nil), nil
+	case token.OpenBracketChar:
+		return l.nextToken(token.OpenBracket{}, nil), nil
+	case token.CloseBracketChar:
+		return l.nextToken(token.CloseBracket{}, nil), nil
+	case token.OpenBraceChar:
+		return l.nextToken(token.OpenBrace{}, nil), nil
+	case token.CloseBraceChar:
+		return l.nextToken(token.CloseBrace{}, nil), nil
+	case token.DotChar:
+		if l.match(token.DotChar) {
+			if l.match(token.DotChar) {
+				return l.nextToken(token.Spread{}, nil), nil
+			}
+			if l.match(token.LesserChar) {
+				return l.nextToken(token.RangeExclusive{}, nil), nil
+			}
+			return l.nextToken(token.Range{}, nil), nil
+		}
+		return l.nextToken(token.Dot{}, nil), nil
 	case nullTerminater:
 		return l.nextToken(token.EOF{}, nil), nil
 	case token.CapChar:
@@ -92,12 +166,28 @@ func (l *Lexer) scan() (*token.Token, error) {
 		return l.nextToken(token.Mod{}, nil), nil
 	case token.CommaChar:
 		return l.nextToken(token.Comma{}, nil), nil
+	case token.SemicolonChar:
+		return l.nextToken(token.Semicolon{}, nil), nil
 	case token.QuoteChar:
 		return l.stringLiteral(token.QuoteChar)
 	case token.DoubleQuoteChar:
 		return l.stringLiteral(token.DoubleQuoteChar)
+	case token.BacktickChar:
+		return l.template()
+	case token.AtChar:
+		return l.quotedIdentifier()
 	case token.QuestionChar:
+		if l.peek(0) == token.QuestionChar {
+			l.eat()
+			return l.nextToken(token.NullCoalesce{}, nil), nil
+		}
+		if l.peek(0) == token.DotChar {
+			l.eat()
+			return l.nextToken(token.OptionalDot{}, nil), nil
+		}
 		return l.nextToken(token.Question{}, nil), nil
+	case token.TildeChar:
+		return l.nextToken(token.BitNot{}, nil), nil
 	case token.ColonChar:
 		return l.nextToken(token.Colon{}, nil), nil
 	case token.PipeChar:
@@ -105,37 +195,61 @@ func (l *Lexer) scan() (*token.Token, error) {
 			l.eat()
 			return l.nextToken(token.Or{}, nil), nil
 		}
-		return nil, fmt.Errorf("Expecting '|' after |")
+		if l.peek(0) == token.GreaterChar {
+			l.eat()
+			return l.nextToken(token.Pipe{}, nil), nil
+		}
+		return l.nextToken(token.BitOr{}, nil), nil
 	case token.AndChar:
 		if l.peek(0) == token.AndChar {
 			l.eat()
 			return l.nextToken(token.And{}, nil), nil
 		}
-		return nil, fmt.Errorf("Expecting '&' after &")
+		return l.nextToken(token.BitAnd{}, nil), nil
 	case token.EqualChar:
 		if l.peek(0) == token.EqualChar {
 			l.eat()
 			return l.nextToken(token.Equal{}, nil), nil
 		}
-		return nil, fmt.Errorf("Expecting '=' after =")
+		if l.peek(0) == token.GreaterChar {
+			l.eat()
+			return l.nextToken(token.Arrow{}, nil), nil
+		}
+		if l.peek(0) == token.TildeChar {
+			l.eat()
+			return l.nextToken(token.Match{}, nil), nil
+		}
+		return l.nextToken(token.Assign{}, nil), nil
 	case token.PunctuationChar:
 		if l.peek(0) == token.EqualChar {
 			l.eat()
 			return l.nextToken(token.NotEqual{}, nil), nil
 		}
+		if l.peek(0) == token.TildeChar {
+			l.eat()
+			return l.nextToken(token.NotMatch{}, nil), nil
+		}
 		return l.nextToken(token.Not{}, nil), nil
 	case token.GreaterChar:
 		if l.peek(0) == token.EqualChar {
 			l.eat()
 			return l.nextToken(token.GreaterEqual{}, nil), nil
 		}
+		if l.peek(0) == token.GreaterChar {
+			l.eat()
+			return l.nextToken(token.ShiftRight{}, nil), nil
+		}
 		return l.nextToken(token.Greater{}, nil), nil
 	case token.LesserChar:
 		if l.peek(0) == token.EqualChar {
 			l.eat()
 			return l.nextToken(token.LesserEqual{}, nil), nil
 		}
-		return l.nextToken(token.LesserEqual{}, nil), nil
+		if l.peek(0) == token.LesserChar {
+			l.eat()
+			return l.nextToken(token.ShiftLeft{}, nil), nil
+		}
+		return l.nextToken(token.Lesser{}, nil), nil
 	}
 	if isDigit(b) {
 		return l.number()
@@ -144,19 +258,31 @@ func (l *Lexer) scan() (*token.Token, error) {
 	if isChar(b) {
 		return l.variable()
 	}
-	//ErrUnexpectedToken #
-	var errUnexpectedToken = fmt.Errorf("Unexpected character %c", b)
-	return nil, errUnexpectedToken
+	return nil, l.syntaxError("Unexpected character %c", b)
 }
 
 func (l *Lexer) number() (*token.Token, error) {
-	l.digits()
-	if l.match('.') {
-		if !isDigit(l.peek(0)) {
-			return nil, ErrInvalidNumber
+	if l.source[l.start] == '0' {
+		switch l.peek(0) {
+		case 'x', 'X':
+			return l.radixNumber(16, "hexadecimal", isHexDigit)
+		case 'b', 'B':
+			return l.radixNumber(2, "binary", isBinaryDigit)
+		case 'o', 'O':
+			return l.radixNumber(8, "octal", isOctalDigit)
 		}
+	}
+	if !l.separatedDigits(isDigit) {
+		return nil, l.invalidNumber("'_' must separate digits")
+	}
+	if l.peek(0) == token.DotChar && l.peek(1) != token.DotChar {
 		l.eat()
-		l.digits()
+		if !isDigit(l.peek(0)) {
+			return nil, l.invalidNumber("expecting digits after '.'")
+		}
+		if !l.separatedDigits(isDigit) {
+			return nil, l.invalidNumber("'_' must separate digits")
+		}
 	}
 
 	if l.match('e') || l.match('E') {
@@ -164,31 +290,131 @@ func (l *Lexer) number() (*token.Token, error) {
 			l.match('+')
 		}
 		if !isDigit(l.peek(0)) {
-			return nil, ErrInvalidNumber
+			return nil, l.invalidNumber("expecting digits in exponent")
+		}
+		if !l.separatedDigits(isDigit) {
+			return nil, l.invalidNumber("'_' must separate digits")
+		}
+	}
+	value, err := decimal.NewFromString(strings.Replace(string(l.source[l.start:l.current]), "_", "", -1))
+	if err != nil {
+		return nil, l.invalidNumber("%v", err)
+	}
+	return l.nextToken(token.Number{}, value), nil
+}
+
+//radixNumber scans 0x, 0b or 0o prefixed integer
+func (l *Lexer) radixNumber(base int, name string, isRadixDigit func(byte) bool) (*token.Token, error) {
+	l.eat()
+	if !isRadixDigit(l.peek(0)) {
+		return nil, l.invalidNumber("expecting %s digits", name)
+	}
+	if !l.separatedDigits(isRadixDigit) {
+		return nil, l.invalidNumber("'_' must separate digits")
+	}
+	if isChar(l.peek(0)) || isDigit(l.peek(0)) {
+		return nil, l.invalidNumber("expecting %s digits", name)
+	}
+	digits := strings.Replace(string(l.source[l.start+2:l.current]), "_", "", -1)
+	value, ok := new(big.Int).SetString(digits, base)
+	if !ok {
+		return nil, l.invalidNumber("expecting %s digits", name)
+	}
+	return l.nextToken(token.Number{}, decimal.NewFromBigInt(value, 0)), nil
+}
+
+//separatedDigits scans digits where single '_' can separate two digits,
+//it tells whether separators are valid
+func (l *Lexer) separatedDigits(isValidDigit func(byte) bool) bool {
+	for {
+		for isValidDigit(l.peek(0)) {
+			l.eat()
+		}
+		if l.peek(0) != '_' {
+			return true
 		}
 		l.eat()
-		l.digits()
+		if !isValidDigit(l.peek(0)) {
+			return false
+		}
 	}
-	value, err := decimal.NewFromString(string(l.source[l.start:l.current]))
-	return l.nextToken(token.Number{}, value), err
 }
 
 func (l *Lexer) variable() (*token.Token, error) {
 	l.characters()
 	value := l.source[int(l.start):int(l.current)]
-	if string(value) == "true" {
+	switch string(value) {
+	case "true":
 		return l.nextToken(token.Boolean{}, true), nil
-	}
-	if string(value) == "false" {
+	case "false":
 		return l.nextToken(token.Boolean{}, false), nil
+	case "null":
+		return l.nextToken(token.Null{}, nil), nil
+	case "let":
+		return l.nextToken(token.Let{}, nil), nil
+	case "in":
+		return l.nextToken(token.In{}, nil), nil
+	case "def":
+		return l.nextToken(token.Def{}, nil), nil
+	case "xor":
+		return l.nextToken(token.BitXor{}, nil), nil
+	case "not":
+		return l.nextToken(token.Not{}, nil), nil
+	case "and":
+		return l.nextToken(token.And{}, nil), nil
+	case "or":
+		return l.nextToken(token.Or{}, nil), nil
+	case "case":
+		return l.nextToken(token.Case{}, nil), nil
+	case "when":
+		return l.nextToken(token.When{}, nil), nil
+	case "then":
+		return l.nextToken(token.Then{}, nil), nil
+	case "else":
+		return l.nextToken(token.Else{}, nil), nil
+	case "end":
+		return l.nextToken(token.End{}, nil), nil
+	}
+	if l.keywordOperator(string(value)) {
+		return l.nextToken(token.Operator{}, nil), nil
 	}
 	return l.nextToken(token.Variable{}, nil), nil
 }
 
+//keywordOperator tells whether keyword is scanned as custom operator, infix operator
+//follows an operand and prefix operator does not, elsewhere it is a name so that
+//function or variable of same name like contains('ab', 'a') is still usable
+func (l *Lexer) keywordOperator(keyword string) bool {
+	if l.afterOperand() {
+		_, ok := l.operators.Infix(keyword)
+		return ok
+	}
+	_, ok := l.operators.Prefix(keyword)
+	return ok
+}
+
+//afterOperand tells whether previous token ends an operand
+func (l *Lexer) afterOperand() bool {
+	if len(l.tokens) == 0 {
+		return false
+	}
+	switch l.tokens[len(l.tokens)-1].Type.Type() {
+	case token.NumberType, token.StringType, token.BooleanType, token.NullType, token.VariableType, token.TemplateType,
+		token.CloseParenType, token.CloseBracketType, token.CloseBraceType, token.EndType:
+		return true
+	}
+	return false
+}
+
 func (l *Lexer) eat() byte {
 	l.current++
 	l.column++
-	return l.source[l.current-1]
+	b := l.source[l.current-1]
+	if b == '\n' {
+		l.line++
+		l.lineStart = l.current
+	}
+	return b
 }
 
 func (l *Lexer) match(expected byte) bool {
@@ -215,6 +441,56 @@ func (l *Lexer) space(b byte) byte {
 	return b
 }
 
+//skip whitespaces and comments before the token, comments are recorded
+func (l *Lexer) skip(b byte) (byte, error) {
+	for {
+		b = l.space(b)
+		if !l.isComment(b) {
+			return b, nil
+		}
+		err := l.comment(b)
+		if err != nil {
+			return 0, err
+		}
+		l.start = l.current
+		if l.isEnd() {
+			return nullTerminater, nil
+		}
+		b = l.eat()
+	}
+}
+
+func (l *Lexer) isComment(b byte) bool {
+	if b == token.HashChar {
+		return true
+	}
+	return b == token.CommonSlashChar && l.peek(0) == token.StarChar
+}
+
+//comment scans # line or /* block */ comment, '//' is floor division and not a comment
+func (l *Lexer) comment(b byte) error {
+	l.startPosition = l.position(l.start)
+	if b == token.CommonSlashChar && l.match(token.StarChar) {
+		for !(l.peek(0) == token.StarChar && l.peek(1) == token.CommonSlashChar) {
+			if l.isEnd() {
+				return l.syntaxError("Expecting */ but found EOF")
+			}
+			l.eat()
+		}
+		l.eat()
+		l.eat()
+	} else {
+		for !l.isEnd() && l.peek(0) != token.NewlineChar {
+			l.eat()
+		}
+	}
+	l.comments = append(l.comments, token.Comment{
+		Text: string(l.source[l.start:l.current]),
+		Span: l.span(),
+	})
+	return nil
+}
+
 func (l Lexer) peek(b uint) byte {
 	if l.current+b >= l.len {
 		return nullTerminater
@@ -229,21 +505,29 @@ func (l *Lexer) isEnd() bool {
 func isEmptySpace(b byte) bool {
 	return b == ' ' || b == '\r' || b == '\t' || b == '\n'
 }
-func (l *Lexer) digits() {
-	for !l.isEnd() && isDigit(l.peek(0)) {
-		l.eat()
-	}
-}
+
 func isDigit(b byte) bool {
 	return b >= '0' && b <= '9'
 }
 
+func isHexDigit(b byte) bool {
+	return isDigit(b) || b >= 'a' && b <= 'f' || b >= 'A' && b <= 'F'
+}
+
+func isOctalDigit(b byte) bool {
+	return b >= '0' && b <= '7'
+}
+
+func isBinaryDigit(b byte) bool {
+	return b == '0' || b == '1'
+}
+
 func isChar(b byte) bool {
 	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b == '_'
 }
 
 func (l *Lexer) characters() {
-	for !l.isEnd() && (isChar(l.peek(0)) || isDigit(l.peek(0)) || l.peek(0) == '.') {
+	for !l.isEnd() && (isChar(l.peek(0)) || isDigit(l.peek(0))) {
 		l.eat()
 	}
 }
@@ -254,19 +538,209 @@ func (l *Lexer) nextToken(tokenType token.Type, literal interface{}) *token.Toke
 		Literal: literal,
 		Lexeme:  string(l.source[l.start:l.current]),
 		Column:  l.column,
+		Span:    l.span(),
+	}
+}
+
+//span of the token being scanned
+func (l *Lexer) span() token.Span {
+	return token.Span{Start: l.startPosition, End: l.position(l.current)}
+}
+
+func (l *Lexer) syntaxError(format string, a ...interface{}) error {
+	return diagnostic.NewSyntaxError(l.span(), format, a...)
+}
+
+//invalidNumber is SyntaxError which wraps ErrInvalidNumber,
+//rest of the malformed literal is consumed so that span covers all of it
+func (l *Lexer) invalidNumber(format string, a ...interface{}) error {
+	for isChar(l.peek(0)) || isDigit(l.peek(0)) {
+		l.eat()
+	}
+	literal := string(l.source[l.start:l.current])
+	return &diagnostic.SyntaxError{
+		Diagnostic: diagnostic.Diagnostic{
+			Span:    l.span(),
+			Code:    diagnostic.CodeSyntax,
+			Message: fmt.Sprintf("%v %s: %s", ErrInvalidNumber, literal, fmt.Sprintf(format, a...)),
+			Err:     ErrInvalidNumber,
+		},
+	}
+}
+
+//position of offset in the current line
+func (l *Lexer) position(offset uint) token.Position {
+	position := token.Position{Offset: offset, Line: l.line, Column: offset - l.lineStart + 1}
+	if l.base.Line == 0 {
+		return position
+	}
+	if position.Line == 1 {
+		position.Column += l.base.Column - 1
 	}
+	position.Offset += l.base.Offset
+	position.Line += l.base.Line - 1
+	return position
 }
 
 func (l *Lexer) stringLiteral(s byte) (*token.Token, error) {
+	var builder strings.Builder
 	for {
 		if l.isEnd() {
-			return nil, fmt.Errorf("Expecting %c but found EOF", s)
+			return nil, l.syntaxError("Expecting %c but found EOF", s)
+		}
+		b := l.eat()
+		if b == s {
+			break
+		}
+		if b == token.BackslashChar {
+			err := l.escape(&builder)
+			if err != nil {
+				return nil, err
+			}
+			continue
 		}
-		if l.peek(0) == s {
+		builder.WriteByte(b)
+	}
+	return l.nextToken(token.LiteralString{}, builder.String()), nil
+}
+
+//quotedIdentifier is variable name with any characters in quotes after '@', @"Unit Price"
+func (l *Lexer) quotedIdentifier() (*token.Token, error) {
+	quote := l.peek(0)
+	if quote != token.QuoteChar && quote != token.DoubleQuoteChar {
+		return nil, l.syntaxError("Expecting quoted name after %c", token.AtChar)
+	}
+	l.eat()
+	name, err := l.stringLiteral(quote)
+	if err != nil {
+		return nil, err
+	}
+	if name.Literal.(string) == "" {
+		return nil, l.syntaxError("Expecting name in quoted identifier %s", name.Lexeme)
+	}
+	name.Type = token.Variable{}
+	return name, nil
+}
+
+//escape writes character of escape sequence which follows backslash
+func (l *Lexer) escape(builder *strings.Builder) error {
+	if l.isEnd() {
+		return l.syntaxError("Expecting escape sequence but found EOF")
+	}
+	b := l.eat()
+	switch b {
+	case 'n':
+		builder.WriteByte('\n')
+	case 't':
+		builder.WriteByte('\t')
+	case 'r':
+		builder.WriteByte('\r')
+	case '0':
+		builder.WriteByte(nullTerminater)
+	case token.BackslashChar, token.QuoteChar, token.DoubleQuoteChar, token.BacktickChar, token.DollarChar:
+		builder.WriteByte(b)
+	case 'u':
+		if l.current+4 > l.len {
+			return l.syntaxError("Expecting 4 hex digits after \\u")
+		}
+		code, err := strconv.ParseUint(string(l.source[l.current:l.current+4]), 16, 32)
+		if err != nil {
+			return l.syntaxError("Invalid unicode escape \\u%s", l.source[l.current:l.current+4])
+		}
+		for i := 0; i < 4; i++ {
 			l.eat()
+		}
+		builder.WriteRune(rune(code))
+	default:
+		// unknown escape is kept as it is so that regex like '\d+' can be written without doubling backslash
+		builder.WriteByte(token.BackslashChar)
+		builder.WriteByte(b)
+	}
+	return nil
+}
+
+//template scans `text ${expression} text` into text and expression source parts
+func (l *Lexer) template() (*token.Token, error) {
+	var parts []token.TemplatePart
+	var builder strings.Builder
+	textStart := l.position(l.current)
+	for {
+		if l.isEnd() {
+			return nil, l.syntaxError("Expecting %c but found EOF", token.BacktickChar)
+		}
+		b := l.eat()
+		if b == token.BacktickChar {
 			break
 		}
-		l.eat()
+		if b == token.BackslashChar {
+			err := l.escape(&builder)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if b == token.DollarChar && l.peek(0) == token.OpenBraceChar {
+			l.eat()
+			if builder.Len() > 0 {
+				span := token.Span{Start: textStart, End: l.position(l.current - 2)}
+				parts = append(parts, token.TemplatePart{Text: builder.String(), Span: span})
+				builder.Reset()
+			}
+			position := l.position(l.current)
+			source, err := l.templateExpression()
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, token.TemplatePart{Text: source, IsExpression: true, Position: position})
+			textStart = l.position(l.current)
+			continue
+		}
+		builder.WriteByte(b)
+	}
+	if builder.Len() > 0 {
+		span := token.Span{Start: textStart, End: l.position(l.current - 1)}
+		parts = append(parts, token.TemplatePart{Text: builder.String(), Span: span})
+	}
+	return l.nextToken(token.Template{}, parts), nil
+}
+
+//templateExpression scans source of expression embedded in template till matching }
+func (l *Lexer) templateExpression() (string, error) {
+	start := l.current
+	depth := 0
+	for {
+		if l.isEnd() {
+			return "", l.syntaxError("Expecting } but found EOF")
+		}
+		b := l.eat()
+		switch b {
+		case token.OpenBraceChar:
+			depth++
+		case token.CloseBraceChar:
+			if depth == 0 {
+				return string(l.source[start : l.current-1]), nil
+			}
+			depth--
+		case token.QuoteChar, token.DoubleQuoteChar, token.BacktickChar:
+			err := l.skipQuoted(b)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+}
+
+func (l *Lexer) skipQuoted(s byte) error {
+	for {
+		if l.isEnd() {
+			return l.syntaxError("Expecting %c but found EOF", s)
+		}
+		b := l.eat()
+		if b == s {
+			return nil
+		}
+		if b == token.BackslashChar && !l.isEnd() {
+			l.eat()
+		}
 	}
-	return l.nextToken(token.LiteralString{}, string(l.source[l.start+1:l.current-1])), nil
 }
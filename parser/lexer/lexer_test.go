@@ -1,8 +1,11 @@
 package lexer
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
+	"github.com/5anthosh/chili/diagnostic"
 	"github.com/5anthosh/chili/parser/token"
 	"github.com/shopspring/decimal"
 )
@@ -52,6 +55,200 @@ func TestLexerNormalExpression(t *testing.T) {
 	}
 }
 
+func TestLexerMemberAccess(t *testing.T) {
+	lex := FromString("user.age[0]")
+	tokens := []token.Token{
+		{Type: token.Variable{}, Lexeme: "user", Column: 4},
+		{Type: token.Dot{}, Lexeme: ".", Column: 5},
+		{Type: token.Variable{}, Lexeme: "age", Column: 8},
+		{Type: token.OpenBracket{}, Lexeme: "[", Column: 9},
+		{Type: token.Number{}, Literal: decimal.Zero, Lexeme: "0", Column: 10},
+		{Type: token.CloseBracket{}, Lexeme: "]", Column: 11},
+	}
+	for _, tt := range tokens {
+		t.Run(tt.Lexeme, func(t *testing.T) {
+			got, err := lex.Next()
+			if err != nil {
+				t.Errorf("Lexer.Next() error = %v, wantErr %v", err, false)
+				return
+			}
+			testLocalToken(t, tt, *got)
+		})
+	}
+}
+
+func TestLexerNumber(t *testing.T) {
+	tests := []struct {
+		source  string
+		want    string
+		wantErr string
+	}{
+		{source: "0xFF", want: "255"},
+		{source: "0Xff_ff", want: "65535"},
+		{source: "0b1010", want: "10"},
+		{source: "0B1111_0000", want: "240"},
+		{source: "0o755", want: "493"},
+		{source: "0O17", want: "15"},
+		{source: "1_000_000", want: "1000000"},
+		{source: "1_000.000_5", want: "1000.0005"},
+		{source: "1e1_0", want: "10000000000"},
+		{source: "0755", want: "755"},
+		{source: "0x", wantErr: "Invalid number 0x: expecting hexadecimal digits"},
+		{source: "0x_1", wantErr: "Invalid number 0x_1: expecting hexadecimal digits"},
+		{source: "0b102", wantErr: "Invalid number 0b102: expecting binary digits"},
+		{source: "0o8", wantErr: "Invalid number 0o8: expecting octal digits"},
+		{source: "1__0", wantErr: "Invalid number 1__0: '_' must separate digits"},
+		{source: "1_", wantErr: "Invalid number 1_: '_' must separate digits"},
+		{source: "1_.5", wantErr: "Invalid number 1_: '_' must separate digits"},
+		{source: "1.", wantErr: "Invalid number 1.: expecting digits after '.'"},
+		{source: "2e", wantErr: "Invalid number 2e: expecting digits in exponent"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			got, err := FromString(tt.source).Next()
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrInvalidNumber) || err.Error() != tt.wantErr {
+					t.Fatalf("Lexer.Next() error = %v, want %v", err, tt.wantErr)
+				}
+				var syntaxErr *diagnostic.SyntaxError
+				if !errors.As(err, &syntaxErr) || syntaxErr.Span.Start.Offset != 0 {
+					t.Errorf("Lexer.Next() error %v is not positioned at the literal", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Lexer.Next() error = %v", err)
+			}
+			if got.Literal.(decimal.Decimal).String() != tt.want {
+				t.Errorf("Lexer.Next().Literal = %v, want %v", got.Literal, tt.want)
+			}
+		})
+	}
+}
+
+func TestLexerStringEscape(t *testing.T) {
+	tests := []struct {
+		source  string
+		want    string
+		wantErr bool
+	}{
+		{source: `'it\'s'`, want: "it's"},
+		{source: `"say \"hi\""`, want: `say "hi"`},
+		{source: `'a\nb\tc\\'`, want: "a\nb\tc\\"},
+		{source: `'caf\u00e9'`, want: "café"},
+		{source: `'\d+'`, want: `\d+`},
+		{source: `'\u00z1'`, wantErr: true},
+		{source: `'open`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			got, err := FromString(tt.source).Next()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Lexer.Next() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && got.Literal != tt.want {
+				t.Errorf("Lexer.Next().Literal == %q, want %q", got.Literal, tt.want)
+			}
+		})
+	}
+}
+
+func TestLexerTemplate(t *testing.T) {
+	got, err := FromString("`Hi ${name}, ${ {a: '}'}.a }\\\\${x}`").Next()
+	if err != nil {
+		t.Fatalf("Lexer.Next() error = %v", err)
+	}
+	want := []token.TemplatePart{
+		{Text: "Hi ", Span: token.Span{Start: token.Position{Offset: 1, Line: 1, Column: 2}, End: token.Position{Offset: 4, Line: 1, Column: 5}}},
+		{Text: "name", IsExpression: true, Position: token.Position{Offset: 6, Line: 1, Column: 7}},
+		{Text: ", ", Span: token.Span{Start: token.Position{Offset: 11, Line: 1, Column: 12}, End: token.Position{Offset: 13, Line: 1, Column: 14}}},
+		{Text: " {a: '}'}.a ", IsExpression: true, Position: token.Position{Offset: 15, Line: 1, Column: 16}},
+		{Text: "\\", Span: token.Span{Start: token.Position{Offset: 28, Line: 1, Column: 29}, End: token.Position{Offset: 30, Line: 1, Column: 31}}},
+		{Text: "x", IsExpression: true, Position: token.Position{Offset: 32, Line: 1, Column: 33}},
+	}
+	parts := got.Literal.([]token.TemplatePart)
+	if len(parts) != len(want) {
+		t.Fatalf("Lexer.Next().Literal == %v, want %v", parts, want)
+	}
+	for i := range want {
+		if parts[i] != want[i] {
+			t.Errorf("template part %d == %v, want %v", i, parts[i], want[i])
+		}
+	}
+}
+
+func TestLexerSpan(t *testing.T) {
+	lex := FromString("1 +\n  foo")
+	want := []token.Span{
+		{Start: token.Position{Offset: 0, Line: 1, Column: 1}, End: token.Position{Offset: 1, Line: 1, Column: 2}},
+		{Start: token.Position{Offset: 2, Line: 1, Column: 3}, End: token.Position{Offset: 3, Line: 1, Column: 4}},
+		{Start: token.Position{Offset: 6, Line: 2, Column: 3}, End: token.Position{Offset: 9, Line: 2, Column: 6}},
+	}
+	for i := range want {
+		got, err := lex.Next()
+		if err != nil {
+			t.Fatalf("Lexer.Next() error = %v", err)
+		}
+		if got.Span != want[i] {
+			t.Errorf("token %d Span == %v, want %v", i, got.Span, want[i])
+		}
+	}
+}
+
+func TestLexerComments(t *testing.T) {
+	source := "a # line\n/* block\n * / */ / b # hash\n/**/c #"
+	lex := FromString(source)
+	var lexemes []string
+	for {
+		got, err := lex.Next()
+		if err != nil {
+			t.Fatalf("Lexer.Next() error = %v", err)
+		}
+		if got.Type.Type() == token.EOFType {
+			break
+		}
+		lexemes = append(lexemes, got.Lexeme)
+	}
+	if strings.Join(lexemes, " ") != "a / b c" {
+		t.Errorf("Lexer.Next() lexemes = %v, want [a / b c]", lexemes)
+	}
+	want := []string{"# line", "/* block\n * / */", "# hash", "/**/", "#"}
+	comments := lex.Comments()
+	if len(comments) != len(want) {
+		t.Fatalf("Lexer.Comments() = %v, want %v", comments, want)
+	}
+	for i := range want {
+		if comments[i].Text != want[i] {
+			t.Errorf("Lexer.Comments()[%d] = %q, want %q", i, comments[i].Text, want[i])
+		}
+		span := comments[i].Span
+		if source[span.Start.Offset:span.End.Offset] != want[i] {
+			t.Errorf("Lexer.Comments()[%d].Span covers %q", i, source[span.Start.Offset:span.End.Offset])
+		}
+	}
+	if comments[2].Span.Start.String() != "3:13" {
+		t.Errorf("Lexer.Comments()[2] at %v, want 3:13", comments[2].Span.Start)
+	}
+	if _, err := FromString("1 /* open").Next(); err != nil {
+		t.Errorf("Lexer.Next() error = %v, want token before comment", err)
+	}
+	lex = FromString("1 /* open")
+	lex.Next()
+	if _, err := lex.Next(); err == nil {
+		t.Errorf("Lexer.Next() expected error for unterminated block comment")
+	}
+}
+
+func TestLexerFloorSlashWithoutOperand(t *testing.T) {
+	lex := FromString("// note")
+	_, err := lex.Next()
+	var syntaxErr *diagnostic.SyntaxError
+	if !errors.As(err, &syntaxErr) || !strings.Contains(err.Error(), "use # for line comment") {
+		t.Errorf("Lexer.Next() error = %v, want SyntaxError suggesting #", err)
+	}
+}
+
 func testLocalToken(t *testing.T, tt token.Token, got token.Token) {
 	if tt.Column != got.Column {
 		t.Errorf("Lexer.Next().Column == %v, want %v", got.Column, tt.Column)
@@ -72,3 +269,40 @@ func testLocalToken(t *testing.T, tt token.Token, got token.Token) {
 		t.Errorf("Lexer.Next().Lexeme == %v, want %v", got.Lexeme, tt.Lexeme)
 	}
 }
+
+func TestLexerRange(t *testing.T) {
+	lex := FromString("1..<10...x")
+	tokens := []token.Token{
+		{Type: token.Number{}, Literal: decimal.NewFromInt(1), Lexeme: "1", Column: 1},
+		{Type: token.RangeExclusive{}, Lexeme: "..<", Column: 4},
+		{Type: token.Number{}, Literal: decimal.NewFromInt(10), Lexeme: "10", Column: 6},
+		{Type: token.Spread{}, Lexeme: "...", Column: 9},
+		{Type: token.Variable{}, Lexeme: "x", Column: 10},
+	}
+	for _, tt := range tokens {
+		t.Run(tt.Lexeme, func(t *testing.T) {
+			got, err := lex.Next()
+			if err != nil {
+				t.Errorf("Lexer.Next() error = %v, wantErr %v", err, false)
+				return
+			}
+			testLocalToken(t, tt, *got)
+		})
+	}
+}
+
+func TestLexerQuotedIdentifier(t *testing.T) {
+	got, err := FromString(`@"Unit \"Price\""`).Next()
+	if err != nil {
+		t.Fatalf("Lexer.Next() error = %v", err)
+	}
+	testLocalToken(t, token.Token{Type: token.Variable{}, Lexeme: `@"Unit \"Price\""`, Column: 17}, *got)
+	if got.Name() != `Unit "Price"` {
+		t.Errorf("Lexer.Next().Name() = %q, want %q", got.Name(), `Unit "Price"`)
+	}
+	for _, source := range []string{"@", "@x", `@""`, `@"x`} {
+		if _, err := FromString(source).Next(); !errors.As(err, new(*diagnostic.SyntaxError)) {
+			t.Errorf("Lexer.Next(%q) error = %v, want SyntaxError", source, err)
+		}
+	}
+}
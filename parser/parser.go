@@ -3,40 +3,414 @@ package parser
 import (
 	"errors"
 	"fmt"
+	"regexp"
 
+	"github.com/5anthosh/chili/diagnostic"
+	"github.com/5anthosh/chili/function"
+	"github.com/5anthosh/chili/operator"
 	"github.com/5anthosh/chili/parser/ast/expr"
+	"github.com/5anthosh/chili/parser/ast/stmt"
 	"github.com/5anthosh/chili/parser/lexer"
 	"github.com/5anthosh/chili/parser/token"
 )
 
 //Parser struct
 type Parser struct {
-	source string
-	lex    *lexer.Lexer
-	n      uint
-	tokens []*token.Token
+	source  string
+	lex     *lexer.Lexer
+	n       uint
+	tokens  []*token.Token
+	options Options
+	// noIn stops binary operators at 'in' which ends let bindings
+	noIn bool
+	// pipeOperand is set while parsing right operand of '|>', piped value is
+	// prepended to arguments of that call so pipeExpr checks its pattern
+	pipeOperand bool
+	// newlineEnds is set while parsing statement of script outside brackets,
+	// newline ends it before binary operator and postfix '(' or '['
+	newlineEnds bool
+	// recovering records syntax errors instead of stopping at the first one
+	recovering bool
+	errors     []error
+}
+
+//Options of the parser
+type Options struct {
+	//Operators are custom operators in addition to builtin operators
+	Operators *operator.Table
 }
 
 //New Parser
 func New(source string) *Parser {
+	return NewWithOptions(source, Options{})
+}
+
+//NewWithOptions creates parser with options
+func NewWithOptions(source string, options Options) *Parser {
 	newParser := new(Parser)
 	newParser.source = source
+	newParser.options = options
 	newParser.lex = lexer.FromString(source)
+	newParser.lex.SetOperators(options.Operators)
 	newParser.tokens = make([]*token.Token, 0)
 	return newParser
 }
 
-//Parse the expression and returns AST
+//embedded creates parser with same options for source embedded at base position
+func (p *Parser) embedded(source string, base token.Position) *Parser {
+	newParser := NewWithOptions(source, p.options)
+	newParser.lex = lexer.FromStringAt(source, base)
+	newParser.lex.SetOperators(p.options.Operators)
+	return newParser
+}
+
+//Parse the expression and returns AST, whole source has to be single expression
 func (p *Parser) Parse() (expr.Expr, error) {
-	return p.expression()
+	expression, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	t, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if t.Type.Type() != token.EOFType {
+		return nil, diagnostic.NewSyntaxError(t.Span, "Expecting end of expression but found %s", t.Lexeme)
+	}
+	return expression, nil
+}
+
+//Comments in the source which is parsed so far
+func (p *Parser) Comments() []token.Comment {
+	return p.lex.Comments()
+}
+
+//ParseRecovering parses the expression without stopping at the first syntax error,
+//source which fails to parse is replaced by expr.Bad and all syntax errors are returned
+func (p *Parser) ParseRecovering() (expr.Expr, []error) {
+	p.recovering = true
+	p.errors = nil
+	defer func() {
+		p.recovering = false
+	}()
+	expression, err := p.recoverable(p.expression)
+	if err != nil {
+		return nil, append(p.errors, err)
+	}
+	t, err := p.peek()
+	if err != nil {
+		return expression, append(p.errors, err)
+	}
+	if t.Type.Type() != token.EOFType {
+		p.errors = append(p.errors, diagnostic.NewSyntaxError(t.Span, "Expecting end of expression but found %s", t.Lexeme))
+	}
+	return expression, p.errors
+}
+
+//ParseScript parses statements separated by ';' or newline, statement continues on next line
+//only when the line ends with binary operator or the newline is inside brackets
+func (p *Parser) ParseScript() ([]stmt.Stmt, error) {
+	var statements []stmt.Stmt
+	for {
+		ok, err := p.match([]uint{token.SemicolonType})
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			continue
+		}
+		ok, err = p.isAtEnd()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return statements, nil
+		}
+		p.newlineEnds = true
+		statement, err := p.statement()
+		p.newlineEnds = false
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, statement)
+		err = p.endOfStatement()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+//ParseFunction parses single function definition def name(a, b) = body
+func (p *Parser) ParseFunction() (*stmt.Def, error) {
+	err := p.consume(token.DefType, "Expecting 'def' at start of function definition")
+	if err != nil {
+		return nil, err
+	}
+	def, err := p.def()
+	if err != nil {
+		return nil, err
+	}
+	t, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if t.Type.Type() != token.EOFType {
+		return nil, diagnostic.NewSyntaxError(t.Span, "Expecting end of function definition but found %s", t.Lexeme)
+	}
+	return def, nil
+}
+
+func (p *Parser) statement() (stmt.Stmt, error) {
+	ok, err := p.match([]uint{token.DefType})
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return p.def()
+	}
+	start := p.n
+	ok, err = p.match([]uint{token.VariableType})
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		name := p.previous()
+		ok, err = p.match([]uint{token.AssignType})
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			value, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			return &stmt.Assign{Name: name.Name(), Value: value, Location: name.Span.To(value.Span())}, nil
+		}
+		p.n = start
+	}
+	expression, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	return &stmt.Expression{Expression: expression, Location: expression.Span()}, nil
+}
+
+func (p *Parser) def() (*stmt.Def, error) {
+	start := p.previous()
+	err := p.identifier("Expecting function name after def")
+	if err != nil {
+		return nil, err
+	}
+	def := &stmt.Def{Name: p.previous().Name()}
+	err = p.consume(token.OpenParenType, fmt.Sprintf("Expecting '(' after function name %s", def.Name))
+	if err != nil {
+		return nil, err
+	}
+	ok, err := p.match([]uint{token.CloseParenType})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		for {
+			err = p.identifier(fmt.Sprintf("Expecting parameter name in %s()", def.Name))
+			if err != nil {
+				return nil, err
+			}
+			param := p.previous()
+			for _, name := range def.Params {
+				if name == param.Name() {
+					return nil, diagnostic.NewSyntaxError(param.Span, "Duplicate parameter %s in %s()", name, def.Name)
+				}
+			}
+			def.Params = append(def.Params, param.Name())
+			ok, err = p.match([]uint{token.CommaType})
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+		}
+		err = p.consume(token.CloseParenType, fmt.Sprintf("Expecting ')' after parameters of %s()", def.Name))
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = p.consume(token.AssignType, fmt.Sprintf("Expecting '=' before body of %s()", def.Name))
+	if err != nil {
+		return nil, err
+	}
+	body, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	def.Body = body
+	def.Location = start.Span.To(body.Span())
+	return def, nil
+}
+
+//identifier consumes name, keyword in place of the name is reported as reserved word
+func (p *Parser) identifier(message string) error {
+	t, err := p.peek()
+	if err != nil {
+		return err
+	}
+	if t.Type.Type() != token.VariableType && token.IsKeyword(t.Lexeme) {
+		return diagnostic.NewSyntaxError(t.Span, "'%s' is a reserved word and cannot be used as a name", t.Lexeme)
+	}
+	return p.consume(token.VariableType, message)
+}
+
+//endOfStatement expects ';', newline or EOF after statement
+func (p *Parser) endOfStatement() error {
+	t, err := p.peek()
+	if err != nil {
+		return err
+	}
+	if t.Type.Type() == token.EOFType || t.Type.Type() == token.SemicolonType {
+		return nil
+	}
+	if t.Span.Start.Line > p.previous().Span.End.Line {
+		return nil
+	}
+	return diagnostic.NewSyntaxError(t.Span, "Expecting ';' or newline after statement but found %s", t.Lexeme)
 }
 
 func (p *Parser) expression() (expr.Expr, error) {
+	ok, err := p.match([]uint{token.LetType})
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return p.let()
+	}
+	start, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	params, ok, err := p.lambdaParams()
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		body, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		return &expr.Lambda{Params: params, Body: body, Location: start.Span.To(body.Span())}, nil
+	}
 	return p.ternary()
 }
 
+func (p *Parser) let() (expr.Expr, error) {
+	start := p.previous()
+	letExpr := &expr.Let{}
+	for {
+		err := p.identifier("Expecting variable name in let")
+		if err != nil {
+			return nil, err
+		}
+		name := p.previous().Name()
+		err = p.consume(token.AssignType, fmt.Sprintf("Expecting '=' after %s in let", name))
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.withIn(false, p.expression)
+		if err != nil {
+			return nil, err
+		}
+		letExpr.Names = append(letExpr.Names, name)
+		letExpr.Values = append(letExpr.Values, value)
+		ok, err := p.match([]uint{token.CommaType})
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+	}
+	err := p.consume(token.InType, "Expecting 'in' after let bindings")
+	if err != nil {
+		return nil, err
+	}
+	body, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	letExpr.Body = body
+	letExpr.Location = start.Span.To(body.Span())
+	return letExpr, nil
+}
+
+//lambdaParams looks ahead for `x =>` or `(a, b) =>` and consumes it,
+//otherwise parser position is restored
+func (p *Parser) lambdaParams() ([]string, bool, error) {
+	start := p.n
+	paramTokens, ok, err := p.scanLambdaParams()
+	if err != nil || !ok {
+		p.n = start
+		return nil, false, err
+	}
+	var params []string
+	for _, param := range paramTokens {
+		for _, name := range params {
+			if name == param.Name() {
+				return nil, false, diagnostic.NewSyntaxError(param.Span, "Duplicate parameter %s in lambda", name)
+			}
+		}
+		params = append(params, param.Name())
+	}
+	return params, true, nil
+}
+
+func (p *Parser) scanLambdaParams() ([]*token.Token, bool, error) {
+	ok, err := p.match([]uint{token.VariableType})
+	if err != nil {
+		return nil, false, err
+	}
+	if ok {
+		params := []*token.Token{p.previous()}
+		ok, err = p.match([]uint{token.ArrowType})
+		return params, ok, err
+	}
+	ok, err = p.match([]uint{token.OpenParenType})
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	var params []*token.Token
+	ok, err = p.match([]uint{token.CloseParenType})
+	if err != nil {
+		return nil, false, err
+	}
+	if !ok {
+		for {
+			ok, err = p.match([]uint{token.VariableType})
+			if err != nil || !ok {
+				return nil, false, err
+			}
+			params = append(params, p.previous())
+			ok, err = p.match([]uint{token.CommaType})
+			if err != nil {
+				return nil, false, err
+			}
+			if !ok {
+				break
+			}
+		}
+		ok, err = p.match([]uint{token.CloseParenType})
+		if err != nil || !ok {
+			return nil, false, err
+		}
+	}
+	ok, err = p.match([]uint{token.ArrowType})
+	return params, ok, err
+}
+
+//ternary is the loosest and right associative, condition ? true : false
 func (p *Parser) ternary() (expr.Expr, error) {
-	expression, err := p.logical()
+	expression, err := p.recoverable(func() (expr.Expr, error) {
+		return p.binary(operator.PrecedenceCoalesce)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -45,205 +419,448 @@ func (p *Parser) ternary() (expr.Expr, error) {
 		return nil, err
 	}
 	if !ok {
-		return expression, nil
+		return expression, nil
+	}
+
+	trueExpr, err := p.recoverable(p.expression)
+	if err != nil {
+		return nil, err
+	}
+	ok, err = p.match([]uint{token.ColonType})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, p.errorAtPeek("Expecting : in ternary operation")
+	}
+	falseExpr, err := p.recoverable(p.expression)
+	if err != nil {
+		return nil, err
+	}
+	return &expr.Ternary{
+		Condition: expression,
+		True:      trueExpr,
+		False:     falseExpr,
+		Location:  expression.Span().To(falseExpr.Span()),
+	}, nil
+}
+
+//binary parses operators of precedence table which bind at least as tight as minimum
+func (p *Parser) binary(minimum uint) (expr.Expr, error) {
+	expression, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		start := p.n
+		t, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if t.Type.Type() == token.InType && p.noIn || p.onNextLine(t) {
+			return expression, nil
+		}
+		if t.Type.Type() == token.NotType {
+			t, err = p.notIn(t)
+			if err != nil || t == nil {
+				return expression, err
+			}
+		}
+		rule, ok := binaryOperators[t.Type.Type()]
+		if !ok {
+			rule, ok = p.customOperator(t)
+		}
+		if !ok || rule.precedence < minimum {
+			p.n = start
+			return expression, nil
+		}
+		p.increment()
+		next := rule.precedence + 1
+		if rule.rightAssociative {
+			next = rule.precedence
+		}
+		p.pipeOperand = t.Type.Type() == token.PipeType
+		right, err := p.binary(next)
+		p.pipeOperand = false
+		if err != nil {
+			return nil, err
+		}
+		expression, err = rule.build(expression, t, right)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+//withIn parses with 'in' operator allowed or not, let bindings disallow it
+//since 'in' ends them
+func (p *Parser) withIn(allowed bool, parse func() (expr.Expr, error)) (expr.Expr, error) {
+	noIn := p.noIn
+	p.noIn = !allowed
+	defer func() {
+		p.noIn = noIn
+	}()
+	return parse()
+}
+
+//nested parses expression inside brackets where 'in' operator is allowed again
+func (p *Parser) nested() (expr.Expr, error) {
+	newlineEnds := p.newlineEnds
+	p.newlineEnds = false
+	defer func() {
+		p.newlineEnds = newlineEnds
+	}()
+	return p.withIn(true, p.expression)
+}
+
+//onNextLine tells whether statement ends at newline before the token, so that
+//line starting with '-', '(' or '[' is not glued to the previous statement
+func (p *Parser) onNextLine(t *token.Token) bool {
+	return p.newlineEnds && p.n > 0 && t.Span.Start.Line > p.previous().Span.End.Line
+}
+
+//notIn consumes 'not' when it is followed by 'in' and returns NotIn token for both,
+//nil is returned when 'not' is not followed by 'in'
+func (p *Parser) notIn(not *token.Token) (*token.Token, error) {
+	if p.noIn || not.Lexeme != "not" {
+		return nil, nil
+	}
+	p.increment()
+	in, err := p.peek()
+	if err != nil || in.Type.Type() != token.InType {
+		p.n--
+		return nil, err
+	}
+	return &token.Token{Type: token.NotIn{}, Lexeme: "not in", Column: not.Column, Span: not.Span.To(in.Span)}, nil
+}
+
+func (p *Parser) unary() (expr.Expr, error) {
+	t, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if op, ok := p.options.Operators.Prefix(t.Lexeme); ok && t.Type.Type() == token.OperatorType {
+		p.increment()
+		right, err := p.binary(op.Precedence)
+		if err != nil {
+			return nil, err
+		}
+		return &expr.Unary{Operator: t, Right: right, Location: t.Span.To(right.Span())}, nil
+	}
+	ok, err := p.match([]uint{token.PlusType, token.MinusType, token.NotType, token.BitNotType})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return p.functionCall()
+	}
+
+	t = p.previous()
+	operand := p.unary
+	if t.Lexeme == "not" {
+		//word form binds looser than comparison, not a == b is not (a == b)
+		operand = func() (expr.Expr, error) {
+			return p.binary(operator.PrecedenceEquality)
+		}
+	}
+	unaryExpr, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	return &expr.Unary{Operator: t, Right: unaryExpr, Location: t.Span.To(unaryExpr.Span())}, nil
+
+}
+
+func (p *Parser) functionCall() (expr.Expr, error) {
+	expression, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, err := p.peek()
+		if err != nil {
+			return nil, err
+		}
+		if p.onNextLine(t) && (t.Type.Type() == token.OpenParenType || t.Type.Type() == token.OpenBracketType) {
+			return expression, nil
+		}
+		ok, err := p.match([]uint{token.OpenParenType})
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			expression, err = p.finishCall(expression)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		ok, err = p.match([]uint{token.OpenBracketType})
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			index, err := p.recoverable(p.nested)
+			if err != nil {
+				return nil, err
+			}
+			err = p.consume(token.CloseBracketType, "Expecting ']' after index")
+			if err != nil {
+				return nil, err
+			}
+			expression = &expr.Index{Object: expression, Index: index, Location: expression.Span().To(p.previous().Span)}
+			continue
+		}
+		ok, err = p.match([]uint{token.DotType, token.OptionalDotType})
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			optional := p.previous().Type.Type() == token.OptionalDotType
+			err = p.consume(token.VariableType, fmt.Sprintf("Expecting field name after '%s'", p.previous().Lexeme))
+			if err != nil {
+				return nil, err
+			}
+			expression = &expr.Member{
+				Object:   expression,
+				Name:     p.previous().Name(),
+				Optional: optional,
+				Location: expression.Span().To(p.previous().Span),
+			}
+			continue
+		}
+		return expression, nil
+	}
+}
+
+func (p *Parser) finishCall(callee expr.Expr) (expr.Expr, error) {
+	piped := p.pipeOperand
+	p.pipeOperand = false
+	switch callee.(type) {
+	case *expr.Variable:
+		name := callee.(*expr.Variable).Name
+		var args []expr.Expr
+		var names []string
+		for {
+			//name of the argument is looked ahead inside recoverable so that
+			//lexer error at the start of argument is recovered like the argument
+			var argName string
+			arg, err := p.recoverable(func() (expr.Expr, error) {
+				if len(args) == 0 {
+					empty, err := p.check(token.CloseParenType)
+					if err != nil || empty {
+						return nil, err
+					}
+				}
+				var err error
+				argName, err = p.argumentName()
+				if err != nil {
+					return nil, err
+				}
+				if argName == "" && names != nil {
+					return nil, p.errorAtPeek("Expecting named argument, positional argument cannot follow named arguments")
+				}
+				return p.argument()
+			})
+			if err != nil {
+				return nil, err
+			}
+			if arg == nil {
+				break
+			}
+			if _, spread := arg.(*expr.Spread); spread && argName != "" {
+				return nil, diagnostic.NewSyntaxError(arg.Span(), "Spread argument cannot be named")
+			}
+			if argName != "" && names == nil {
+				names = make([]string, len(args))
+			}
+			if names != nil {
+				names = append(names, argName)
+			}
+			args = append(args, arg)
+			ok, err := p.separator(token.CloseParenType, "Expecting ')' after arguments")
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				break
+			}
+		}
+		ok, err := p.match([]uint{token.CloseParenType})
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, p.errorAtPeek("Expecting ')' after arguments")
+		}
+		call := &expr.FunctionCall{Name: name, Args: args, Names: names, Location: callee.Span().To(p.previous().Span)}
+		if !piped {
+			err = checkCallPattern(call)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return call, nil
+	}
+	return nil, diagnostic.NewSyntaxError(callee.Span().To(p.previous().Span), "Expecting function before '('")
+}
+
+//argument of function call, ...list is spread into arguments
+func (p *Parser) argument() (expr.Expr, error) {
+	ok, err := p.match([]uint{token.SpreadType})
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return p.nested()
 	}
-
-	trueExpr, err := p.expression()
+	start := p.previous()
+	expression, err := p.nested()
 	if err != nil {
 		return nil, err
 	}
+	return &expr.Spread{Expression: expression, Location: start.Span.To(expression.Span())}, nil
+}
+
+//argumentName consumes `name:` of named argument, empty name is returned for positional argument
+func (p *Parser) argumentName() (string, error) {
+	start := p.n
+	ok, err := p.match([]uint{token.VariableType})
+	if err != nil || !ok {
+		return "", err
+	}
+	name := p.previous().Name()
 	ok, err = p.match([]uint{token.ColonType})
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	if !ok {
-		return nil, fmt.Errorf("Expecting : in ternary operation")
-	}
-	falseExpr, err := p.expression()
-	if err != nil {
-		return nil, err
+		p.n = start
+		return "", nil
 	}
-	return &expr.Ternary{
-		Condition: expression,
-		True:      trueExpr,
-		False:     falseExpr,
-	}, nil
+	return name, nil
 }
 
-func (p *Parser) logical() (expr.Expr, error) {
-	expression, err := p.equality()
+func (p *Parser) list() (expr.Expr, error) {
+	start := p.previous()
+	var elements []expr.Expr
+	ok, err := p.match([]uint{token.CloseBracketType})
 	if err != nil {
 		return nil, err
 	}
+	if ok {
+		return &expr.List{Elements: elements, Location: start.Span.To(p.previous().Span)}, nil
+	}
 	for {
-		ok, err := p.match([]uint{token.AndType, token.OrType})
+		element, err := p.recoverable(p.nested)
 		if err != nil {
 			return nil, err
 		}
-		if !ok {
-			break
-		}
-
-		operator := p.previous()
-		right, err := p.equality()
+		elements = append(elements, element)
+		ok, err := p.separator(token.CloseBracketType, "Expecting ']' after list elements")
 		if err != nil {
 			return nil, err
 		}
-		expression = &expr.Logical{Left: expression, Right: right, Operator: operator}
+		if !ok {
+			break
+		}
 	}
-	return expression, nil
+	err = p.consume(token.CloseBracketType, "Expecting ']' after list elements")
+	if err != nil {
+		return nil, err
+	}
+	return &expr.List{Elements: elements, Location: start.Span.To(p.previous().Span)}, nil
 }
 
-func (p *Parser) equality() (expr.Expr, error) {
-	expression, err := p.addition()
+func (p *Parser) term() (expr.Expr, error) {
+	ok, err := p.match([]uint{token.NumberType, token.StringType, token.BooleanType, token.NullType})
 	if err != nil {
 		return nil, err
 	}
-	for {
-		ok, err := p.match([]uint{
-			token.EqualType,
-			token.NotEqualType,
-			token.GreaterType,
-			token.GreaterEqualType,
-			token.LesserType,
-			token.LesserEqualType,
-		})
-		if err != nil {
-			return nil, err
-		}
-		if !ok {
-			break
-		}
-
-		operator := p.previous()
-		right, err := p.addition()
-		if err != nil {
-			return nil, err
-		}
-		expression = &expr.Binary{Left: expression, Right: right, Operator: operator}
+	if ok {
+		literal := p.previous()
+		return &expr.Literal{Value: literal.Literal, Location: literal.Span}, nil
 	}
-	return expression, nil
-}
 
-func (p *Parser) addition() (expr.Expr, error) {
-	expression, err := p.multiply()
+	ok, err = p.match([]uint{token.TemplateType})
 	if err != nil {
 		return nil, err
 	}
-	for {
-		ok, err := p.match([]uint{token.PlusType, token.MinusType})
-		if err != nil {
-			return nil, err
-		}
-		if !ok {
-			break
-		}
-
-		operator := p.previous()
-		right, err := p.multiply()
-		if err != nil {
-			return nil, err
-		}
-		expression = &expr.Binary{Left: expression, Right: right, Operator: operator}
+	if ok {
+		return p.template(p.previous())
 	}
-	return expression, nil
-}
 
-func (p *Parser) multiply() (expr.Expr, error) {
-	expression, err := p.exponent()
+	ok, err = p.match([]uint{token.VariableType})
 	if err != nil {
 		return nil, err
 	}
-	for {
-		ok, err := p.match([]uint{token.StarType, token.CommonSlashType, token.CapType, token.ModType})
-		if err != nil {
-			return nil, err
-		}
-		if !ok {
-			break
-		}
-
-		operator := p.previous()
-		right, err := p.exponent()
-		if err != nil {
-			return nil, err
-		}
-		expression = &expr.Binary{Left: expression, Right: right, Operator: operator}
+	if ok {
+		variableExpression := p.previous()
+		return &expr.Variable{Name: variableExpression.Name(), Location: variableExpression.Span}, nil
 	}
-	return expression, nil
-}
 
-func (p *Parser) exponent() (expr.Expr, error) {
-	expression, err := p.unary()
+	ok, err = p.match([]uint{token.OpenParenType})
 	if err != nil {
 		return nil, err
 	}
-	for {
-		ok, err := p.match([]uint{token.CapType})
-		if err != nil {
-			return nil, err
-		}
-		if !ok {
-			break
-		}
-		operator := p.previous()
-		right, err := p.unary()
-		if err != nil {
-			return nil, err
-		}
-		expression = &expr.Binary{Left: expression, Right: right, Operator: operator}
+	if ok {
+		return p.group()
 	}
-	return expression, nil
-}
 
-func (p *Parser) unary() (expr.Expr, error) {
-	ok, err := p.match([]uint{token.PlusType, token.MinusType, token.NotType})
+	ok, err = p.match([]uint{token.OpenBracketType})
 	if err != nil {
 		return nil, err
 	}
-	if !ok {
-		return p.functionCall()
+	if ok {
+		return p.list()
 	}
 
-	t := p.previous()
-	unaryExpr, err := p.functionCall()
+	ok, err = p.match([]uint{token.OpenBraceType})
 	if err != nil {
 		return nil, err
 	}
-	return &expr.Unary{Operator: t, Right: unaryExpr}, nil
+	if ok {
+		return p.mapLiteral()
+	}
 
+	ok, err = p.match([]uint{token.CaseType})
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return p.caseExpr()
+	}
+	t, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	peekValue := "EOF"
+	if t.Type.Type() != token.EOFType {
+		peekValue = t.Lexeme
+	}
+	return nil, diagnostic.NewSyntaxError(t.Span, "Expect Expression but found %s", peekValue)
 }
 
-func (p *Parser) functionCall() (expr.Expr, error) {
-	expression, err := p.term()
+//group is (expression) or tuple (a, b, c) which is a list literal
+func (p *Parser) group() (expr.Expr, error) {
+	start := p.previous()
+	expression, err := p.recoverable(p.nested)
 	if err != nil {
 		return nil, err
 	}
-	ok, err := p.match([]uint{token.OpenParenType})
+	ok, err := p.match([]uint{token.CommaType})
 	if err != nil {
 		return nil, err
 	}
-	if !ok {
-		return expression, nil
-	}
-	switch expression.(type) {
-	case *expr.Variable:
-		name := expression.(*expr.Variable).Name
-		var args []expr.Expr
-		ok, err := p.match([]uint{token.CloseParenType})
-		if err != nil {
-			return nil, err
-		}
-		if ok {
-			return &expr.FunctionCall{Name: name, Args: args}, nil
-		}
+	if ok {
+		elements := []expr.Expr{expression}
 		for {
-			arg, err := p.expression()
+			element, err := p.recoverable(p.nested)
 			if err != nil {
 				return nil, err
 			}
-			args = append(args, arg)
-			ok, err := p.match([]uint{token.CommaType})
+			elements = append(elements, element)
+			ok, err = p.match([]uint{token.CommaType})
 			if err != nil {
 				return nil, err
 			}
@@ -251,69 +868,287 @@ func (p *Parser) functionCall() (expr.Expr, error) {
 				break
 			}
 		}
-		ok, err = p.match([]uint{token.CloseParenType})
+		err = p.consume(token.CloseParenType, "Expecting ')' after tuple elements")
 		if err != nil {
 			return nil, err
 		}
-		if ok {
-			return &expr.FunctionCall{Name: name, Args: args}, nil
-		}
-		return nil, errors.New("Expecting ')' after arguments")
+		return &expr.List{Elements: elements, Location: start.Span.To(p.previous().Span)}, nil
+	}
+	peekValue := "EOF"
+	peekToken, err := p.peek()
+	if err != nil {
+		return nil, err
+	}
+	if peekToken.Type.Type() != token.EOFType {
+		peekValue = peekToken.Lexeme
+	}
+	err = p.consume(token.CloseParenType, fmt.Sprintf("Expect ')' after expression but found %s", peekValue))
+	if err != nil {
+		return nil, err
 	}
-	return nil, errors.New("Expecting function before '('")
+	return &expr.Group{Expression: expression, Location: start.Span.To(p.previous().Span)}, nil
 }
 
-func (p *Parser) term() (expr.Expr, error) {
-	ok, err := p.match([]uint{token.NumberType, token.StringType, token.BooleanType})
+//caseExpr is case [subject] when condition then value ... [else default] end
+func (p *Parser) caseExpr() (expr.Expr, error) {
+	start := p.previous()
+	caseExpr := &expr.Case{}
+	ok, err := p.check(token.WhenType)
 	if err != nil {
 		return nil, err
 	}
-	if ok {
-		literal := p.previous()
-		return &expr.Literal{Value: literal.Literal}, nil
+	if !ok {
+		caseExpr.Subject, err = p.recoverable(p.nested)
+		if err != nil {
+			return nil, err
+		}
 	}
-
-	ok, err = p.match([]uint{token.VariableType})
+	for {
+		ok, err := p.match([]uint{token.WhenType})
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		condition, err := p.recoverable(p.nested)
+		if err != nil {
+			return nil, err
+		}
+		err = p.consume(token.ThenType, "Expecting 'then' after case condition")
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.recoverable(p.nested)
+		if err != nil {
+			return nil, err
+		}
+		caseExpr.Conditions = append(caseExpr.Conditions, condition)
+		caseExpr.Values = append(caseExpr.Values, value)
+	}
+	if len(caseExpr.Conditions) == 0 {
+		return nil, p.errorAtPeek("Expecting 'when' in case")
+	}
+	ok, err = p.match([]uint{token.ElseType})
 	if err != nil {
 		return nil, err
 	}
 	if ok {
-		variableExpression := p.previous()
-		return &expr.Variable{Name: variableExpression.Lexeme}, nil
+		caseExpr.Else, err = p.recoverable(p.nested)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = p.consume(token.EndType, "Expecting 'end' after case")
+	if err != nil {
+		return nil, err
 	}
+	caseExpr.Location = start.Span.To(p.previous().Span)
+	return caseExpr, nil
+}
 
-	ok, err = p.match([]uint{token.OpenParenType})
+func (p *Parser) template(t *token.Token) (expr.Expr, error) {
+	templateExpr := &expr.Template{Location: t.Span}
+	for _, part := range t.Literal.([]token.TemplatePart) {
+		if !part.IsExpression {
+			templateExpr.Parts = append(templateExpr.Parts, &expr.Literal{Value: part.Text, Location: part.Span})
+			continue
+		}
+		embedded := p.embedded(part.Text, part.Position)
+		expression, err := embedded.expression()
+		if err != nil {
+			return nil, err
+		}
+		t, err := embedded.peek()
+		if err != nil {
+			return nil, err
+		}
+		if t.Type.Type() != token.EOFType {
+			return nil, diagnostic.NewSyntaxError(t.Span, "Expecting } after template expression but found %s", t.Lexeme)
+		}
+		templateExpr.Parts = append(templateExpr.Parts, expression)
+	}
+	return templateExpr, nil
+}
+
+func (p *Parser) mapLiteral() (expr.Expr, error) {
+	start := p.previous()
+	mapExpr := &expr.Map{}
+	ok, err := p.match([]uint{token.CloseBraceType})
 	if err != nil {
 		return nil, err
 	}
 	if ok {
-		expression, err := p.expression()
+		mapExpr.Location = start.Span.To(p.previous().Span)
+		return mapExpr, nil
+	}
+	for {
+		ok, err := p.match([]uint{token.VariableType, token.StringType})
 		if err != nil {
 			return nil, err
 		}
-		peekValue := "EOF"
-		peekToken, err := p.peek()
+		if !ok {
+			return nil, p.errorAtPeek("Expecting key in map literal")
+		}
+		key := p.previous()
+		name := key.Name()
+		if key.Type.Type() == token.StringType {
+			name = key.Literal.(string)
+		}
+		err = p.consume(token.ColonType, fmt.Sprintf("Expecting ':' after map key %s", key.Lexeme))
 		if err != nil {
 			return nil, err
 		}
-		if peekToken.Type.Type() != token.EOFType {
-			peekValue = peekToken.Lexeme
+		value, err := p.recoverable(p.nested)
+		if err != nil {
+			return nil, err
 		}
-		err = p.consume(token.CloseParenType, fmt.Sprintf("Expect ')' after expression but found %s", peekValue))
+		mapExpr.Keys = append(mapExpr.Keys, name)
+		mapExpr.Values = append(mapExpr.Values, value)
+		ok, err = p.match([]uint{token.CommaType})
 		if err != nil {
 			return nil, err
 		}
-		return &expr.Group{Expression: expression}, nil
+		if !ok {
+			break
+		}
 	}
-	t, err := p.peek()
+	err = p.consume(token.CloseBraceType, "Expecting '}' after map entries")
 	if err != nil {
 		return nil, err
 	}
-	peekValue := "EOF"
-	if t.Type.Type() != token.EOFType {
-		peekValue = t.Lexeme
+	mapExpr.Location = start.Span.To(p.previous().Span)
+	return mapExpr, nil
+}
+
+//recoverable parses with parse, in recovering mode syntax error is recorded
+//and the source till the next synchronizing token is replaced by expr.Bad
+func (p *Parser) recoverable(parse func() (expr.Expr, error)) (expr.Expr, error) {
+	if !p.recovering {
+		return parse()
+	}
+	start := p.n
+	expression, err := parse()
+	if err == nil {
+		return expression, nil
+	}
+	var syntaxErr *diagnostic.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return nil, err
+	}
+	p.errors = append(p.errors, err)
+	return p.synchronize(start, syntaxErr.Span)
+}
+
+//synchronize skips tokens till ',', ')', '?', ':' or case keyword which is not nested in brackets,
+//skipped source from start token is returned as expr.Bad
+func (p *Parser) synchronize(start uint, span token.Span) (expr.Expr, error) {
+	if start < p.n {
+		span = p.tokens[start].Span.To(p.previous().Span)
+	}
+	depth := 0
+	for {
+		t, err := p.peek()
+		if err != nil {
+			if !errors.As(err, new(*diagnostic.SyntaxError)) {
+				return nil, err
+			}
+			p.errors = append(p.errors, err)
+			continue
+		}
+		switch t.Type.Type() {
+		case token.EOFType:
+			return &expr.Bad{Location: span}, nil
+		case token.OpenParenType, token.OpenBracketType, token.OpenBraceType, token.CaseType:
+			depth++
+		case token.CloseParenType, token.CloseBracketType, token.CloseBraceType, token.EndType:
+			if depth == 0 {
+				return &expr.Bad{Location: span}, nil
+			}
+			depth--
+		case token.CommaType, token.QuestionType, token.ColonType, token.WhenType, token.ThenType, token.ElseType:
+			if depth == 0 {
+				return &expr.Bad{Location: span}, nil
+			}
+		}
+		if t.Span.End.Offset > span.End.Offset {
+			span.End = t.Span.End
+		}
+		p.increment()
+	}
+}
+
+//separator matches ',' between items of brackets, false is returned before closing bracket.
+//In recovering mode missing ',' is recorded and tokens are skipped till next ',' or closing
+//bracket, so that remaining items are parsed and the mistake is reported only once
+func (p *Parser) separator(closing uint, message string) (bool, error) {
+	ok, err := p.match([]uint{token.CommaType})
+	if err != nil || ok {
+		return ok, err
+	}
+	ok, err = p.check(closing)
+	if err != nil || ok || !p.recovering {
+		return false, err
+	}
+	err = p.errorAtPeek(message)
+	if !errors.As(err, new(*diagnostic.SyntaxError)) {
+		return false, err
 	}
-	return nil, fmt.Errorf("Expect Expression but found %s", peekValue)
+	p.errors = append(p.errors, err)
+	_, err = p.synchronize(p.n, token.Span{})
+	if err != nil {
+		return false, err
+	}
+	ok, err = p.match([]uint{token.CommaType})
+	if err != nil || ok {
+		return ok, err
+	}
+	ok, err = p.check(closing)
+	if err != nil || ok {
+		return false, err
+	}
+	return false, p.errorAtPeek(message)
+}
+
+//hasSpread tells whether any of the arguments is spread, positions of the
+//arguments which follow it are known only when it is evaluated
+func hasSpread(args []expr.Expr) bool {
+	for _, arg := range args {
+		if _, ok := arg.(*expr.Spread); ok {
+			return true
+		}
+	}
+	return false
+}
+
+//checkCallPattern checks literal pattern of regex function call, piped call is
+//checked after the piped value is prepended to its arguments
+func checkCallPattern(call *expr.FunctionCall) error {
+	args := call.Args
+	if !function.RegexFunctions[call.Name] || len(args) <= function.RegexPatternArg {
+		return nil
+	}
+	if call.Names != nil && call.Names[function.RegexPatternArg] != "" || hasSpread(args[:function.RegexPatternArg+1]) {
+		return nil
+	}
+	return checkPattern(args[function.RegexPatternArg])
+}
+
+//checkPattern reports invalid regular expression when pattern is a literal
+func checkPattern(pattern expr.Expr) error {
+	literal, ok := pattern.(*expr.Literal)
+	if !ok {
+		return nil
+	}
+	source, ok := literal.Value.(string)
+	if !ok {
+		return nil
+	}
+	_, err := regexp.Compile(source)
+	if err != nil {
+		return diagnostic.NewSyntaxError(pattern.Span(), "Invalid regular expression %q: %v", source, err)
+	}
+	return nil
 }
 
 func (p *Parser) getToken() (*token.Token, error) {
@@ -350,7 +1185,16 @@ func (p *Parser) consume(tokenType uint, message string) error {
 		p.increment()
 		return nil
 	}
-	return errors.New(message)
+	return p.errorAtPeek(message)
+}
+
+//errorAtPeek is SyntaxError at the next token
+func (p *Parser) errorAtPeek(message string) error {
+	t, err := p.peek()
+	if err != nil {
+		return err
+	}
+	return diagnostic.NewSyntaxError(t.Span, "%s", message)
 }
 
 func (p *Parser) check(tokenType uint) (bool, error) {
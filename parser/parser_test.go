@@ -0,0 +1,308 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/5anthosh/chili/diagnostic"
+	"github.com/5anthosh/chili/parser/ast/expr"
+)
+
+func TestParseSpan(t *testing.T) {
+	source := "1 + max(a, [2, 3])\n  * `x${y} z\\n\n!`"
+	got, err := New(source).Parse()
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	binary := got.(*expr.Binary)
+	tests := []struct {
+		name string
+		node expr.Expr
+		want string
+	}{
+		{"binary", binary, source},
+		{"left", binary.Left, "1"},
+		{"call", binary.Right.(*expr.Binary).Left, "max(a, [2, 3])"},
+		{"list", binary.Right.(*expr.Binary).Left.(*expr.FunctionCall).Args[1], "[2, 3]"},
+		{"template", binary.Right.(*expr.Binary).Right, "`x${y} z\\n\n!`"},
+		{"text", binary.Right.(*expr.Binary).Right.(*expr.Template).Parts[0], "x"},
+		{"embedded", binary.Right.(*expr.Binary).Right.(*expr.Template).Parts[1], "y"},
+		{"multiline text", binary.Right.(*expr.Binary).Right.(*expr.Template).Parts[2], " z\\n\n!"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			span := tt.node.Span()
+			if got := source[span.Start.Offset:span.End.Offset]; got != tt.want {
+				t.Errorf("Span() covers %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if end := binary.Span().End; end.Line != 3 || end.Column != 3 {
+		t.Errorf("Span().End == %v, want 3:3", end)
+	}
+	text := binary.Right.(*expr.Binary).Right.(*expr.Template).Parts[2].Span()
+	if text.Start.String() != "2:11" || text.End.String() != "3:2" {
+		t.Errorf("Span() of text == %v-%v, want 2:11-3:2", text.Start, text.End)
+	}
+}
+
+func TestParseRecovering(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string
+	}{
+		{name: "valid", source: "max(1, 2) + 3"},
+		{name: "arguments", source: "max(1 +, 2, * 3)", want: []string{"1:8", "1:13"}},
+		{name: "ternary", source: "a > ? b + : c", want: []string{"1:5", "1:11"}},
+		{name: "nested", source: "[(1 *), {a: }, f(,)]", want: []string{"1:6", "1:13", "1:18", "1:19"}},
+		{name: "lexer", source: "max(1 \\ 2, 3 \\ 4)", want: []string{"1:7", "1:14"}},
+		{name: "trailing", source: "max(1, 2))", want: []string{"1:10"}},
+		{name: "case", source: "case when a > then 1 when b then * 2 end", want: []string{"1:15", "1:34"}},
+		{name: "lexer at argument start", source: "max(@x, 2)", want: []string{"1:5"}},
+		{name: "named arguments", source: "round(places: 2, 1, number: * 3)", want: []string{"1:18", "1:29"}},
+		{name: "missing comma", source: "f(1 2 3)", want: []string{"1:5"}},
+		{name: "missing commas", source: "f(a, 1 2 3, b 4)", want: []string{"1:8", "1:15"}},
+		{name: "missing comma in list", source: "[1 2 3, 4]", want: []string{"1:4"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, errs := New(tt.source).ParseRecovering()
+			if got == nil {
+				t.Fatalf("ParseRecovering() returned no expression")
+			}
+			if len(errs) != len(tt.want) {
+				t.Fatalf("ParseRecovering() errors = %v, want %d errors", errs, len(tt.want))
+			}
+			for i, err := range errs {
+				var syntaxErr *diagnostic.SyntaxError
+				if !errors.As(err, &syntaxErr) {
+					t.Fatalf("ParseRecovering() error = %v, want SyntaxError", err)
+				}
+				if position := syntaxErr.Span.Start.String(); position != tt.want[i] {
+					t.Errorf("ParseRecovering() error %q at %s, want %s", err, position, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseRecoveringPartialAST(t *testing.T) {
+	got, errs := New("max(1 +, 2)").ParseRecovering()
+	if len(errs) != 1 {
+		t.Fatalf("ParseRecovering() errors = %v", errs)
+	}
+	call := got.(*expr.FunctionCall)
+	if len(call.Args) != 2 {
+		t.Fatalf("ParseRecovering() args = %d, want 2", len(call.Args))
+	}
+	bad, ok := call.Args[0].(*expr.Bad)
+	if !ok {
+		t.Fatalf("ParseRecovering() first arg = %T, want *expr.Bad", call.Args[0])
+	}
+	if start, end := bad.Span().Start.Offset, bad.Span().End.Offset; start != 4 || end != 7 {
+		t.Errorf("Bad.Span() = %d..%d, want 4..7", start, end)
+	}
+	if _, err := New("max(1 +, 2)").Parse(); err == nil {
+		t.Errorf("Parse() expected to fail fast")
+	}
+}
+
+//parenthesize renders expression with explicit grouping for the conformance tests
+func parenthesize(e expr.Expr) string {
+	switch node := e.(type) {
+	case *expr.Binary:
+		return fmt.Sprintf("(%s %s %s)", parenthesize(node.Left), node.Operator.Lexeme, parenthesize(node.Right))
+	case *expr.Logical:
+		return fmt.Sprintf("(%s %s %s)", parenthesize(node.Left), node.Operator.Lexeme, parenthesize(node.Right))
+	case *expr.Unary:
+		if node.Operator.Lexeme == "not" {
+			return fmt.Sprintf("(not %s)", parenthesize(node.Right))
+		}
+		return fmt.Sprintf("(%s%s)", node.Operator.Lexeme, parenthesize(node.Right))
+	case *expr.Ternary:
+		return fmt.Sprintf("(%s ? %s : %s)", parenthesize(node.Condition), parenthesize(node.True), parenthesize(node.False))
+	case *expr.Group:
+		return parenthesize(node.Expression)
+	case *expr.Literal:
+		return fmt.Sprintf("%v", node.Value)
+	case *expr.Variable:
+		return node.Name
+	case *expr.FunctionCall:
+		args := make([]string, len(node.Args))
+		for i, arg := range node.Args {
+			args[i] = parenthesize(arg)
+			if node.Names != nil && node.Names[i] != "" {
+				args[i] = node.Names[i] + ": " + args[i]
+			}
+		}
+		return fmt.Sprintf("%s(%s)", node.Name, strings.Join(args, ", "))
+	case *expr.List:
+		items := make([]string, len(node.Elements))
+		for i, item := range node.Elements {
+			items[i] = parenthesize(item)
+		}
+		return fmt.Sprintf("[%s]", strings.Join(items, ", "))
+	case *expr.Case:
+		var builder strings.Builder
+		builder.WriteString("(case")
+		if node.Subject != nil {
+			builder.WriteString(" " + parenthesize(node.Subject))
+		}
+		for i, condition := range node.Conditions {
+			builder.WriteString(fmt.Sprintf(" when %s then %s", parenthesize(condition), parenthesize(node.Values[i])))
+		}
+		if node.Else != nil {
+			builder.WriteString(" else " + parenthesize(node.Else))
+		}
+		builder.WriteString(" end)")
+		return builder.String()
+	case *expr.Spread:
+		return "..." + parenthesize(node.Expression)
+	case *expr.Index:
+		return fmt.Sprintf("%s[%s]", parenthesize(node.Object), parenthesize(node.Index))
+	case *expr.Member:
+		return fmt.Sprintf("%s.%s", parenthesize(node.Object), node.Name)
+	}
+	return fmt.Sprintf("<%T>", e)
+}
+
+//TestParsePrecedence documents the grammar, operators from the loosest to the tightest:
+//
+//	?:                   right associative
+//	??
+//	||
+//	&&
+//	unary not           word form, operand runs till the next && or ||
+//	== != =~ !~
+//	> >= < <= in not in
+//	|>
+//	|
+//	xor
+//	&
+//	<< >>
+//	.. ..<
+//	+ -
+//	* / // %
+//	^                    right associative
+//	unary - + ! ~
+//	call, index, member
+func TestParsePrecedence(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"1 + 2 * 3", "(1 + (2 * 3))"},
+		{"1 - 2 - 3", "((1 - 2) - 3)"},
+		{"8 / 4 / 2", "((8 / 4) / 2)"},
+		{"2 * 3 % 4", "((2 * 3) % 4)"},
+		{"2 ^ 3 ^ 2", "(2 ^ (3 ^ 2))"},
+		{"2 * 3 ^ 2", "(2 * (3 ^ 2))"},
+		{"2 ^ 3 * 2", "((2 ^ 3) * 2)"},
+		{"-2 ^ 2", "((-2) ^ 2)"},
+		{"2 ^ -1", "(2 ^ (-1))"},
+		{"!a && b", "((!a) && b)"},
+		{"!!a", "(!(!a))"},
+		{"- -1", "(-(-1))"},
+		{"-~a", "(-(~a))"},
+		{"not s == 'x'", "(not (s == x))"},
+		{"not a < b and c", "((not (a < b)) and c)"},
+		{"not not a", "(not (not a))"},
+		{"a == not b", "(a == (not b))"},
+		{"not x in xs", "(not (x in xs))"},
+		{"!s == 'x'", "((!s) == x)"},
+		{"a || b && c", "(a || (b && c))"},
+		{"a && b || c", "((a && b) || c)"},
+		{"a == b && c != d", "((a == b) && (c != d))"},
+		{"a < b == c > d", "((a < b) == (c > d))"},
+		{"a <= b", "(a <= b)"},
+		{"1 + 2 < 3 * 4", "((1 + 2) < (3 * 4))"},
+		{"s =~ 'x' || t !~ 'y'", "((s =~ x) || (t !~ y))"},
+		{"a ?? b || c", "(a ?? (b || c))"},
+		{"a ?? b ?? c", "((a ?? b) ?? c)"},
+		{"a ? b : c ? d : e", "(a ? b : (c ? d : e))"},
+		{"a || b ? 1 + 2 : 3", "((a || b) ? (1 + 2) : 3)"},
+		{"1 + 2 |> f(3)", "f((1 + 2), 3)"},
+		{"x |> f |> g(1) > 2", "(g(f(x), 1) > 2)"},
+		{"(1 + 2) * 3", "((1 + 2) * 3)"},
+		{"-f(x)[0].a ^ 2", "((-f(x)[0].a) ^ 2)"},
+		{"7 // 2 * 3", "((7 // 2) * 3)"},
+		{"a | b xor c & d", "(a | (b xor (c & d)))"},
+		{"a & b << 1 + 1", "(a & (b << (1 + 1)))"},
+		{"1 << 2 >> 1", "((1 << 2) >> 1)"},
+		{"a & m == m", "((a & m) == m)"},
+		{"~a & b", "((~a) & b)"},
+		{"a || b | c", "(a || (b | c))"},
+		{"x in 1..n+1", "(x in (1 .. (n + 1)))"},
+		{"x not in 0..<n && y", "((x not in (0 ..< n)) && y)"},
+		{"s in ('a', 'b')", "(s in [a, b])"},
+		{"a in b == c", "((a in b) == c)"},
+		{"f(a in b)", "f((a in b))"},
+		{"case when a > 1 then x + 1 else y end * 2", "((case when (a > 1) then (x + 1) else y end) * 2)"},
+		{"case s when 'a' then 1 when 'b' then 2 end", "(case s when a then 1 when b then 2 end)"},
+		{"case when a then case when b then 1 end end", "(case when a then (case when b then 1 end) end)"},
+		{"let a = case when b in c then 1 end in a", "<*expr.Let>"},
+		{"round(x, places: 1 + 1)", "round(x, places: (1 + 1))"},
+		{"x |> round(places: a ? 1 : 2)", "round(x, places: (a ? 1 : 2))"},
+		{"f(a ? b : c, d: e)", "f((a ? b : c), d: e)"},
+		{"max(...xs, ...a.b ?? [])", "max(...xs, ...(a.b ?? []))"},
+		{"max(...1..n + 1)", "max(...(1 .. (n + 1)))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			got, err := New(tt.source).Parse()
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if parenthesize(got) != tt.want {
+				t.Errorf("Parse() = %s, want %s", parenthesize(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePipedPattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		wantErr string
+	}{
+		{name: "call", source: "regexReplace('abc', 'b', '(')"},
+		{name: "piped replacement", source: "'abc' |> regexReplace('b', '(')"},
+		{name: "piped pattern", source: "s |> regexMatch('[')", wantErr: "1:17"},
+		{name: "piped twice", source: "s |> trim() |> regexMatch('(')", wantErr: "1:27"},
+		{name: "argument of piped call", source: "s |> concat(regexExtract(t, '['))", wantErr: "1:29"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.source).Parse()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Parse() error = %v", err)
+				}
+				return
+			}
+			var syntaxErr *diagnostic.SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Parse() error = %v, want SyntaxError", err)
+			}
+			if position := syntaxErr.Span.Start.String(); position != tt.wantErr {
+				t.Errorf("Parse() error %q at %s, want %s", err, position, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseDuplicateParameter(t *testing.T) {
+	_, err := New("def f(a, a) = a; f(1, 2)").ParseScript()
+	var syntaxErr *diagnostic.SyntaxError
+	if !errors.As(err, &syntaxErr) || syntaxErr.Span.Start.String() != "1:10" {
+		t.Errorf("ParseScript() error = %v, want SyntaxError at 1:10", err)
+	}
+	_, err = New("map(xs, (a, b, a) => a)").Parse()
+	if !errors.As(err, &syntaxErr) || syntaxErr.Span.Start.String() != "1:16" {
+		t.Errorf("Parse() error = %v, want SyntaxError at 1:16", err)
+	}
+}
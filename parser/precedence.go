@@ -0,0 +1,102 @@
+package parser
+
+import (
+	"github.com/5anthosh/chili/diagnostic"
+	"github.com/5anthosh/chili/operator"
+	"github.com/5anthosh/chili/parser/ast/expr"
+	"github.com/5anthosh/chili/parser/token"
+)
+
+//binaryOperator is an entry of the precedence table
+type binaryOperator struct {
+	precedence       uint
+	rightAssociative bool
+	build            func(left expr.Expr, operator *token.Token, right expr.Expr) (expr.Expr, error)
+}
+
+//binaryOperators is the precedence table of builtin binary operators by token type,
+//precedence levels are in operator package so that custom operators can be placed between them,
+//ternary is looser and unary, call, index and member access are tighter than all of them
+var binaryOperators = map[uint]binaryOperator{
+	token.NullCoalesceType:   {precedence: operator.PrecedenceCoalesce, build: logicalExpr},
+	token.OrType:             {precedence: operator.PrecedenceOr, build: logicalExpr},
+	token.AndType:            {precedence: operator.PrecedenceAnd, build: logicalExpr},
+	token.EqualType:          {precedence: operator.PrecedenceEquality, build: binaryExpr},
+	token.NotEqualType:       {precedence: operator.PrecedenceEquality, build: binaryExpr},
+	token.MatchType:          {precedence: operator.PrecedenceEquality, build: matchExpr},
+	token.NotMatchType:       {precedence: operator.PrecedenceEquality, build: matchExpr},
+	token.GreaterType:        {precedence: operator.PrecedenceComparison, build: binaryExpr},
+	token.GreaterEqualType:   {precedence: operator.PrecedenceComparison, build: binaryExpr},
+	token.LesserType:         {precedence: operator.PrecedenceComparison, build: binaryExpr},
+	token.LesserEqualType:    {precedence: operator.PrecedenceComparison, build: binaryExpr},
+	token.InType:             {precedence: operator.PrecedenceComparison, build: binaryExpr},
+	token.NotInType:          {precedence: operator.PrecedenceComparison, build: binaryExpr},
+	token.PipeType:           {precedence: operator.PrecedencePipe, build: pipeExpr},
+	token.BitOrType:          {precedence: operator.PrecedenceBitwiseOr, build: binaryExpr},
+	token.BitXorType:         {precedence: operator.PrecedenceBitwiseXor, build: binaryExpr},
+	token.BitAndType:         {precedence: operator.PrecedenceBitwiseAnd, build: binaryExpr},
+	token.ShiftLeftType:      {precedence: operator.PrecedenceShift, build: binaryExpr},
+	token.ShiftRightType:     {precedence: operator.PrecedenceShift, build: binaryExpr},
+	token.RangeType:          {precedence: operator.PrecedenceRange, build: binaryExpr},
+	token.RangeExclusiveType: {precedence: operator.PrecedenceRange, build: binaryExpr},
+	token.PlusType:           {precedence: operator.PrecedenceAdditive, build: binaryExpr},
+	token.MinusType:          {precedence: operator.PrecedenceAdditive, build: binaryExpr},
+	token.StarType:           {precedence: operator.PrecedenceMultiplicative, build: binaryExpr},
+	token.CommonSlashType:    {precedence: operator.PrecedenceMultiplicative, build: binaryExpr},
+	token.FloorSlashType:     {precedence: operator.PrecedenceMultiplicative, build: binaryExpr},
+	token.ModType:            {precedence: operator.PrecedenceMultiplicative, build: binaryExpr},
+	token.CapType:            {precedence: operator.PrecedenceExponent, rightAssociative: true, build: binaryExpr},
+}
+
+//customOperator is the precedence table entry of custom infix operator
+func (p *Parser) customOperator(t *token.Token) (binaryOperator, bool) {
+	if t.Type.Type() != token.OperatorType {
+		return binaryOperator{}, false
+	}
+	op, ok := p.options.Operators.Infix(t.Lexeme)
+	if !ok {
+		return binaryOperator{}, false
+	}
+	return binaryOperator{precedence: op.Precedence, rightAssociative: op.RightAssociative, build: binaryExpr}, true
+}
+
+func binaryExpr(left expr.Expr, operator *token.Token, right expr.Expr) (expr.Expr, error) {
+	return &expr.Binary{Left: left, Right: right, Operator: operator, Location: left.Span().To(right.Span())}, nil
+}
+
+func logicalExpr(left expr.Expr, operator *token.Token, right expr.Expr) (expr.Expr, error) {
+	return &expr.Logical{Left: left, Right: right, Operator: operator, Location: left.Span().To(right.Span())}, nil
+}
+
+//matchExpr checks the regex literal while parsing
+func matchExpr(left expr.Expr, operator *token.Token, right expr.Expr) (expr.Expr, error) {
+	err := checkPattern(right)
+	if err != nil {
+		return nil, err
+	}
+	return binaryExpr(left, operator, right)
+}
+
+//pipeExpr x |> f(y) is parsed as f(x, y)
+func pipeExpr(left expr.Expr, operator *token.Token, right expr.Expr) (expr.Expr, error) {
+	location := left.Span().To(right.Span())
+	switch right.(type) {
+	case *expr.FunctionCall:
+		call := right.(*expr.FunctionCall)
+		args := append([]expr.Expr{left}, call.Args...)
+		var names []string
+		if call.Names != nil {
+			names = append([]string{""}, call.Names...)
+		}
+		piped := &expr.FunctionCall{Name: call.Name, Args: args, Names: names, Piped: true, Location: location}
+		err := checkCallPattern(piped)
+		if err != nil {
+			return nil, err
+		}
+		return piped, nil
+	case *expr.Variable:
+		args := []expr.Expr{left}
+		return &expr.FunctionCall{Name: right.(*expr.Variable).Name, Args: args, Piped: true, Location: location}, nil
+	}
+	return nil, diagnostic.NewSyntaxError(right.Span(), "Expecting function call after '|>'")
+}
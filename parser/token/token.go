@@ -4,25 +4,38 @@ import "fmt"
 
 // Token character
 const (
-	PlusChar        = '+'
-	MinusChar       = '-'
-	StarChar        = '*'
-	CommonSlashChar = '/'
-	OpenParenChar   = '('
-	CloseParenChar  = ')'
-	CapChar         = '^'
-	ModChar         = '%'
-	CommaChar       = ','
-	QuoteChar       = '\''
-	DoubleQuoteChar = '"'
-	EqualChar       = '='
-	PunctuationChar = '!'
-	PipeChar        = '|'
-	AndChar         = '&'
-	QuestionChar    = '?'
-	ColonChar       = ':'
-	GreaterChar     = '>'
-	LesserChar      = '<'
+	PlusChar         = '+'
+	MinusChar        = '-'
+	StarChar         = '*'
+	CommonSlashChar  = '/'
+	OpenParenChar    = '('
+	CloseParenChar   = ')'
+	CapChar          = '^'
+	ModChar          = '%'
+	CommaChar        = ','
+	QuoteChar        = '\''
+	DoubleQuoteChar  = '"'
+	EqualChar        = '='
+	PunctuationChar  = '!'
+	PipeChar         = '|'
+	AndChar          = '&'
+	QuestionChar     = '?'
+	ColonChar        = ':'
+	GreaterChar      = '>'
+	LesserChar       = '<'
+	OpenBracketChar  = '['
+	CloseBracketChar = ']'
+	OpenBraceChar    = '{'
+	CloseBraceChar   = '}'
+	DotChar          = '.'
+	BackslashChar    = '\\'
+	BacktickChar     = '`'
+	DollarChar       = '$'
+	TildeChar        = '~'
+	SemicolonChar    = ';'
+	HashChar         = '#'
+	NewlineChar      = '\n'
+	AtChar           = '@'
 )
 
 // Type of tokens
@@ -51,6 +64,41 @@ const (
 	BooleanType
 	QuestionType
 	ColonType
+	OpenBracketType
+	CloseBracketType
+	OpenBraceType
+	CloseBraceType
+	DotType
+	ArrowType
+	NullType
+	NullCoalesceType
+	OptionalDotType
+	TemplateType
+	MatchType
+	NotMatchType
+	AssignType
+	LetType
+	InType
+	SemicolonType
+	DefType
+	PipeType
+	OperatorType
+	FloorSlashType
+	BitAndType
+	BitOrType
+	BitXorType
+	BitNotType
+	ShiftLeftType
+	ShiftRightType
+	NotInType
+	RangeType
+	RangeExclusiveType
+	CaseType
+	WhenType
+	ThenType
+	ElseType
+	EndType
+	SpreadType
 	EOFType
 )
 
@@ -228,7 +276,7 @@ func (NotEqual) Type() uint {
 	return NotEqualType
 }
 
-//And && symbol
+//And && symbol or "and" keyword
 type And struct{}
 
 func (And) String() string {
@@ -240,7 +288,7 @@ func (And) Type() uint {
 	return AndType
 }
 
-//Or || symbol
+//Or || symbol or "or" keyword
 type Or struct{}
 
 func (Or) String() string {
@@ -288,7 +336,7 @@ func (Colon) Type() uint {
 	return ColonType
 }
 
-//Not ! symbol
+//Not ! symbol or "not" keyword
 type Not struct{}
 
 func (Not) String() string {
@@ -348,6 +396,436 @@ func (LesserEqual) Type() uint {
 	return LesserEqualType
 }
 
+//OpenBracket symbol "["
+type OpenBracket struct{}
+
+func (OpenBracket) String() string {
+	return "Open Square Bracket"
+}
+
+//Type of symbol
+func (OpenBracket) Type() uint {
+	return OpenBracketType
+}
+
+//CloseBracket symbol "]"
+type CloseBracket struct{}
+
+func (CloseBracket) String() string {
+	return "Close Square Bracket"
+}
+
+//Type of symbol
+func (CloseBracket) Type() uint {
+	return CloseBracketType
+}
+
+//OpenBrace symbol "{"
+type OpenBrace struct{}
+
+func (OpenBrace) String() string {
+	return "Open Brace"
+}
+
+//Type of symbol
+func (OpenBrace) Type() uint {
+	return OpenBraceType
+}
+
+//CloseBrace symbol "}"
+type CloseBrace struct{}
+
+func (CloseBrace) String() string {
+	return "Close Brace"
+}
+
+//Type of symbol
+func (CloseBrace) Type() uint {
+	return CloseBraceType
+}
+
+//Dot symbol "."
+type Dot struct{}
+
+func (Dot) String() string {
+	return "Dot"
+}
+
+//Type of symbol
+func (Dot) Type() uint {
+	return DotType
+}
+
+//Arrow => symbol
+type Arrow struct{}
+
+func (Arrow) String() string {
+	return "Arrow"
+}
+
+//Type of token
+func (Arrow) Type() uint {
+	return ArrowType
+}
+
+//Null literal
+type Null struct{}
+
+func (Null) String() string {
+	return "Null"
+}
+
+//Type of token
+func (Null) Type() uint {
+	return NullType
+}
+
+//NullCoalesce ?? symbol
+type NullCoalesce struct{}
+
+func (NullCoalesce) String() string {
+	return "Null Coalesce"
+}
+
+//Type of token
+func (NullCoalesce) Type() uint {
+	return NullCoalesceType
+}
+
+//OptionalDot ?. symbol
+type OptionalDot struct{}
+
+func (OptionalDot) String() string {
+	return "Optional Dot"
+}
+
+//Type of token
+func (OptionalDot) Type() uint {
+	return OptionalDotType
+}
+
+//Template string `text ${expression}`
+type Template struct{}
+
+func (Template) String() string {
+	return "Template"
+}
+
+//Type of token
+func (Template) Type() uint {
+	return TemplateType
+}
+
+//TemplatePart is either text or source of embedded expression of template string
+type TemplatePart struct {
+	Text         string
+	IsExpression bool
+	//Position where the embedded expression starts
+	Position Position
+	//Span of the text in the source
+	Span Span
+}
+
+//Match =~ symbol
+type Match struct{}
+
+func (Match) String() string {
+	return "Match"
+}
+
+//Type of token
+func (Match) Type() uint {
+	return MatchType
+}
+
+//NotMatch !~ symbol
+type NotMatch struct{}
+
+func (NotMatch) String() string {
+	return "Not Match"
+}
+
+//Type of token
+func (NotMatch) Type() uint {
+	return NotMatchType
+}
+
+//Assign = symbol
+type Assign struct{}
+
+func (Assign) String() string {
+	return "Assign"
+}
+
+//Type of token
+func (Assign) Type() uint {
+	return AssignType
+}
+
+//Let keyword
+type Let struct{}
+
+func (Let) String() string {
+	return "Let"
+}
+
+//Type of token
+func (Let) Type() uint {
+	return LetType
+}
+
+//In keyword
+type In struct{}
+
+func (In) String() string {
+	return "In"
+}
+
+//Type of token
+func (In) Type() uint {
+	return InType
+}
+
+//Semicolon ; symbol
+type Semicolon struct{}
+
+func (Semicolon) String() string {
+	return "Semicolon"
+}
+
+//Type of token
+func (Semicolon) Type() uint {
+	return SemicolonType
+}
+
+//Def keyword
+type Def struct{}
+
+func (Def) String() string {
+	return "Def"
+}
+
+//Type of token
+func (Def) Type() uint {
+	return DefType
+}
+
+//Pipe |> symbol
+type Pipe struct{}
+
+func (Pipe) String() string {
+	return "Pipe"
+}
+
+//Type of token
+func (Pipe) Type() uint {
+	return PipeType
+}
+
+//Operator is custom operator symbol or keyword
+type Operator struct{}
+
+func (Operator) String() string {
+	return "Operator"
+}
+
+//Type of symbol
+func (Operator) Type() uint {
+	return OperatorType
+}
+
+//FloorSlash symbol "//"
+type FloorSlash struct{}
+
+func (FloorSlash) String() string {
+	return "Floor Slash"
+}
+
+//Type of symbol
+func (FloorSlash) Type() uint {
+	return FloorSlashType
+}
+
+//BitAnd symbol "&"
+type BitAnd struct{}
+
+func (BitAnd) String() string {
+	return "Bit And"
+}
+
+//Type of symbol
+func (BitAnd) Type() uint {
+	return BitAndType
+}
+
+//BitOr symbol "|"
+type BitOr struct{}
+
+func (BitOr) String() string {
+	return "Bit Or"
+}
+
+//Type of symbol
+func (BitOr) Type() uint {
+	return BitOrType
+}
+
+//BitXor keyword "xor"
+type BitXor struct{}
+
+func (BitXor) String() string {
+	return "Bit Xor"
+}
+
+//Type of symbol
+func (BitXor) Type() uint {
+	return BitXorType
+}
+
+//BitNot symbol "~"
+type BitNot struct{}
+
+func (BitNot) String() string {
+	return "Bit Not"
+}
+
+//Type of symbol
+func (BitNot) Type() uint {
+	return BitNotType
+}
+
+//ShiftLeft symbol "<<"
+type ShiftLeft struct{}
+
+func (ShiftLeft) String() string {
+	return "Shift Left"
+}
+
+//Type of symbol
+func (ShiftLeft) Type() uint {
+	return ShiftLeftType
+}
+
+//ShiftRight symbol ">>"
+type ShiftRight struct{}
+
+func (ShiftRight) String() string {
+	return "Shift Right"
+}
+
+//Type of symbol
+func (ShiftRight) Type() uint {
+	return ShiftRightType
+}
+
+//NotIn keywords "not in"
+type NotIn struct{}
+
+func (NotIn) String() string {
+	return "Not In"
+}
+
+//Type of symbol
+func (NotIn) Type() uint {
+	return NotInType
+}
+
+//Range symbol ".."
+type Range struct{}
+
+func (Range) String() string {
+	return "Range"
+}
+
+//Type of symbol
+func (Range) Type() uint {
+	return RangeType
+}
+
+//RangeExclusive symbol "..<"
+type RangeExclusive struct{}
+
+func (RangeExclusive) String() string {
+	return "Range Exclusive"
+}
+
+//Type of symbol
+func (RangeExclusive) Type() uint {
+	return RangeExclusiveType
+}
+
+//Case keyword
+type Case struct{}
+
+func (Case) String() string {
+	return "Case"
+}
+
+//Type of keyword
+func (Case) Type() uint {
+	return CaseType
+}
+
+//When keyword
+type When struct{}
+
+func (When) String() string {
+	return "When"
+}
+
+//Type of keyword
+func (When) Type() uint {
+	return WhenType
+}
+
+//Then keyword
+type Then struct{}
+
+func (Then) String() string {
+	return "Then"
+}
+
+//Type of keyword
+func (Then) Type() uint {
+	return ThenType
+}
+
+//Else keyword
+type Else struct{}
+
+func (Else) String() string {
+	return "Else"
+}
+
+//Type of keyword
+func (Else) Type() uint {
+	return ElseType
+}
+
+//End keyword
+type End struct{}
+
+func (End) String() string {
+	return "End"
+}
+
+//Type of keyword
+func (End) Type() uint {
+	return EndType
+}
+
+//Spread symbol "..."
+type Spread struct{}
+
+func (Spread) String() string {
+	return "Spread"
+}
+
+//Type of symbol
+func (Spread) Type() uint {
+	return SpreadType
+}
+
 //EOF symbol
 type EOF struct{}
 
@@ -360,14 +838,78 @@ func (e EOF) Type() uint {
 	return EOFType
 }
 
+//Position of a character in the source, Line and Column start from 1
+type Position struct {
+	Offset uint
+	Line   uint
+	Column uint
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+//Span of the source from Start till End (exclusive)
+type Span struct {
+	Start Position
+	End   Position
+}
+
+//To creates span from start of s till end of other
+func (s Span) To(other Span) Span {
+	return Span{Start: s.Start, End: other.End}
+}
+
+//Comment skipped by the lexer, Text includes the delimiters
+type Comment struct {
+	Text string
+	Span Span
+}
+
 //Token character stream of expression is token into token
 type Token struct {
 	Type    Type
 	Literal interface{}
 	Lexeme  string
 	Column  uint
+	Span    Span
+}
+
+//Name of the identifier, quoted identifier @"Unit Price" keeps the name without quotes in Literal
+func (t Token) Name() string {
+	if name, ok := t.Literal.(string); ok && t.Type.Type() == VariableType {
+		return name
+	}
+	return t.Lexeme
 }
 
 func (t Token) String() string {
 	return fmt.Sprintf("< %s %s %v %d>", t.Type.String(), t.Lexeme, t.Literal, t.Column)
 }
+
+//keywords cannot be used as names of variables, functions or parameters
+var keywords = map[string]bool{
+	"true": true, "false": true, "null": true, "let": true, "in": true, "def": true,
+	"xor": true, "not": true, "and": true, "or": true,
+	"case": true, "when": true, "then": true, "else": true, "end": true,
+}
+
+//reserved symbols of builtin operators
+var reserved = map[string]bool{
+	"+": true, "-": true, "*": true, "/": true, "%": true, "^": true, "!": true,
+	"=": true, "==": true, "!=": true, "=>": true, "=~": true, "!~": true,
+	">": true, ">=": true, "<": true, "<=": true, "&&": true, "||": true,
+	"??": true, "?.": true, "|>": true,
+	"//": true, "&": true, "|": true, "~": true, "<<": true, ">>": true,
+	"..": true, "..<": true, "...": true, "@": true,
+}
+
+//IsKeyword tells whether word is reserved keyword
+func IsKeyword(word string) bool {
+	return keywords[word]
+}
+
+//IsReserved tells whether symbol is keyword or builtin operator
+func IsReserved(symbol string) bool {
+	return keywords[symbol] || reserved[symbol]
+}